@@ -0,0 +1,51 @@
+package backoff
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// _abandoned counts calls of Fn abandoned but still running of all Run
+var _abandoned atomic.Int64
+
+// AbandonedGoroutines returns count of abandoned Fn calls still running in the process,
+// see Conf.AbandonAfter. Use Snapshot.Abandoned for the count of a single Runner.
+func AbandonedGoroutines() int64 {
+	return _abandoned.Load()
+}
+
+// _AbandonAfter fails with *ErrorFnAbandoned if Fn not returned within AbandonAfter
+// once ctx canceled, the returned chan is the same as errChan if not abandoned.
+func (b Backoff) _AbandonAfter(ctx context.Context, errChan <-chan error) <-chan error {
+	// set capacity to 1 to avoid goroutine leak
+	result := make(chan error, 1)
+	go func() {
+		select {
+		case err := <-errChan:
+			result <- err
+			return
+		case <-ctx.Done():
+		}
+
+		timer, stop := _NewTimer(b.Clock(), b.Config.AbandonAfter)
+		select {
+		case err := <-errChan:
+			stop()
+			result <- err
+			return
+		case <-timer:
+		}
+
+		abandoned := CtxAbandoned{}.Must(ctx)
+		_abandoned.Add(1)
+		result <- &ErrorFnAbandoned{
+			Cause:   context.Cause(ctx),
+			After:   b.Config.AbandonAfter,
+			Running: abandoned.Add(1),
+		}
+		<-errChan
+		abandoned.Add(-1)
+		_abandoned.Add(-1)
+	}()
+	return result
+}
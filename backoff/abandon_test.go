@@ -0,0 +1,88 @@
+package backoff
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoff_AbandonAfter(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	var count atomic.Uint32
+	runner := New(func(ctx context.Context) error {
+		if count.Add(1) == 1 {
+			// ignore cancellation
+			<-release
+			return ctx.Err()
+		}
+		return nil
+	}, Conf{
+		Logger:          _NewDiscardLogger(),
+		InitialDuration: time.Millisecond,
+		AttemptTimeout:  time.Millisecond * 10,
+		AbandonAfter:    time.Millisecond * 10,
+		MaxHistory:      2,
+	}).Start(context.Background())
+	result, err := runner.Wait()
+	require.NoError(t, err, "next attempt should proceed")
+	require.Len(t, result.Attempts, 2)
+
+	var abandonErr *ErrorFnAbandoned
+	require.ErrorAs(t, result.Attempts[0].Err, &abandonErr)
+	assert.ErrorIs(t, abandonErr, ErrCauseAttemptTimeout)
+	assert.Equal(t, time.Millisecond*10, abandonErr.After)
+	assert.EqualValues(t, 1, abandonErr.Running)
+	assert.EqualValues(t, 1, runner.Snapshot().Abandoned)
+	assert.GreaterOrEqual(t, AbandonedGoroutines(), int64(1), "should be counted in aggregate")
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		return runner.Snapshot().Abandoned == 0
+	}, time.Second, time.Millisecond, "should not count returned Fn")
+}
+
+func TestBackoff_AbandonAfter_HealthCheck(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	defer close(release)
+	_, err := New(func(ctx context.Context) error {
+		<-release
+		return nil
+	}, Conf{
+		Logger:       _NewDiscardLogger(),
+		Restart:      RestartNever,
+		AbandonAfter: time.Millisecond * 10,
+		HealthChecker: func(ctx context.Context) <-chan error {
+			errChan := make(chan error, 1)
+			errChan <- assert.AnError
+			return errChan
+		},
+	}).RunWithResult(context.Background())
+
+	var abandonErr *ErrorFnAbandoned
+	require.ErrorAs(t, err, &abandonErr)
+	var healthErr *ErrorHealthCheckFailed
+	assert.ErrorAs(t, err, &healthErr, "should keep cause of canceling")
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestBackoff_AbandonAfter_Returned(t *testing.T) {
+	t.Parallel()
+
+	err := New(func(ctx context.Context) error {
+		<-ctx.Done()
+		return assert.AnError
+	}, Conf{
+		Logger:         _NewDiscardLogger(),
+		Restart:        RestartNever,
+		AttemptTimeout: time.Millisecond,
+		AbandonAfter:   time.Second,
+	}).Run(context.Background())
+	assert.ErrorIs(t, err, assert.AnError, "should not be abandoned if Fn returned in time")
+}
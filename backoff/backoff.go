@@ -2,9 +2,11 @@ package backoff
 
 import (
 	"context"
-	"fmt"
-	log "github.com/sirupsen/logrus"
+	"errors"
+	"log/slog"
 	"runtime"
+	"slices"
+	"sync/atomic"
 	"time"
 )
 
@@ -20,18 +22,72 @@ type Conf struct {
 	Logger          Logger
 	DisableRecovery bool
 
+	// RetryIf decides whether an error returned by Fn should be retried,
+	// *ErrorPanic is passed in as well. Default retry all errors except ErrorPermanent.
+	RetryIf func(err error) bool
+
 	// HealthChecker func will be called while waiting for Fn returning errors.
 	// Once Fn returned anything, the context passed to NewHealthChecker will be canceled.
 	// If error chan return nil, wait time will be reset. Otherwise, the context passed
-	// to Fn and HealthChecker will be canceled.
+	// to Fn and HealthChecker will be canceled, and the attempt fails with ErrorHealthCheckFailed.
 	HealthChecker HealthChecker
+	// HealthResultChecker works like HealthChecker with structured results and takes precedence over it,
+	// HealthWarn results are only reported to Observer.
+	HealthResultChecker HealthResultChecker
+	// HealthCheckWait calculates wait time after health check failures separately, default same as crashes
+	HealthCheckWait *WaitPolicy
 
 	// InitialDuration means initial wait time, default 1 second
 	InitialDuration time.Duration
 	// MaxDuration means maximum retry wait time, default 20 minutes
 	MaxDuration time.Duration
-	// MaxRetry, default unlimited
+	// MaxRetry limits consecutive failures, restarting after success starts counting again. Default unlimited
 	MaxRetry uint
+	// MaxElapsedTime stops retrying when next attempt would start after it since Run, default unlimited
+	MaxElapsedTime time.Duration
+	// FailFast stops retrying when next attempt would start after deadline of the context
+	FailFast bool
+	// StopPolicy works together with MaxRetry, MaxElapsedTime and FailFast
+	StopPolicy StopPolicy
+	// MaxHistory limits attempts kept in Result and passed to StopPolicy, older attempts are dropped.
+	// If 0 and MaxRetry is set, MaxRetry+1 attempts are kept, enough for all consecutive failures.
+	// If both are 0, RunWithResult keeps all attempts, while Run and Start only keep the last one.
+	MaxHistory uint
+
+	// RetryBudget throttles retries, it can be shared by many Conf.
+	// Run gives up with ErrorRetryBudgetExhausted once throttled, unless RetryBudgetWait.
+	RetryBudget *RetryBudget
+	// RetryBudgetWait keeps polling after the wait until the budget is refilled by successes of others,
+	// polls are added to the wait of the attempt but not counted as retries
+	RetryBudgetWait bool
+
+	// AttemptTimeout cancels the context passed to Fn after timeout with cause ErrCauseAttemptTimeout,
+	// default unlimited. It relies on context deadline, so it's not affected by Clock.
+	AttemptTimeout time.Duration
+
+	// HedgeDelay starts another call of Fn if the attempt has not returned after it, default disabled.
+	// The first success wins and other calls are canceled, the attempt fails once all calls failed.
+	// Only use it with idempotent Fn.
+	HedgeDelay time.Duration
+	// HedgeMaxAttempts limits concurrent calls of an attempt, default 2
+	HedgeMaxAttempts uint
+
+	// AbandonAfter gives up waiting for Fn not returning within it after canceled by health check,
+	// heartbeat or AttemptTimeout. The attempt fails with ErrorFnAbandoned and next attempt proceeds,
+	// while Fn is left running and counted by Snapshot.Abandoned and AbandonedGoroutines. Default wait forever.
+	AbandonAfter time.Duration
+
+	// HeartbeatTimeout cancels Fn not calling Heartbeat within it, the attempt fails with
+	// ErrorHeartbeatTimeout. Default disabled.
+	HeartbeatTimeout time.Duration
+	// HeartbeatDumpStacks records stacks of all goroutines in ErrorHeartbeatTimeout.Stack
+	HeartbeatDumpStacks bool
+
+	// Clock is the time source of waits and records, default RealClock
+	Clock Clock
+
+	// Strategy calculates next wait time, default FactorStrategy built with factors below.
+	Strategy Strategy
 
 	// $Next = ($Last + InterConstFactor) * (2 ^ ExponentFactor) + OuterConstFactor
 
@@ -39,6 +95,30 @@ type Conf struct {
 	ExponentFactor   int
 	InterConstFactor time.Duration
 	OuterConstFactor time.Duration
+
+	// ResetDecay divides wait time on every reset instead of resetting to InitialDuration,
+	// e.g. 2 halves wait time per passed health check. Default 0 resets directly.
+	ResetDecay float64
+	// ResetCooldown ignores all resets of wait time until Fn has been running for it,
+	// including resets by health check, success and uptime. Default 0 disables cooldown.
+	// Restarting after success within cooldown waits like after failure.
+	ResetCooldown time.Duration
+	// ResetUptimeInterval resets wait time once per interval of uptime when Fn failed
+	// after running longer than it. Default 0 disables uptime resets.
+	ResetUptimeInterval time.Duration
+
+	// Restart decides whether Fn should be started again after returning, default RestartOnFailure
+	Restart RestartPolicy
+	// MinUptime makes Fn returning nil within MinUptime count as failure with ErrorMinUptime
+	MinUptime time.Duration
+
+	// Observer receives events of retry lifecycle, logging with Logger is always enabled
+	Observer Observer
+
+	// Jitter mode, default JitterNone
+	Jitter Jitter
+	// Rand is the random source used by Jitter, default global source of math/rand/v2
+	Rand Rand
 }
 
 // New backoff instance with default values
@@ -52,6 +132,9 @@ func New(fn func(ctx context.Context) error, c Conf) Backoff {
 	if c.ExponentFactor <= 0 {
 		c.ExponentFactor = 1
 	}
+	if c.Restart == "" {
+		c.Restart = RestartOnFailure
+	}
 	return NewInstance(fn, c)
 }
 
@@ -60,7 +143,7 @@ func New(fn func(ctx context.Context) error, c Conf) Backoff {
 // Consider to use New or set values by hand.
 func NewInstance(fn func(ctx context.Context) error, conf Conf) Backoff {
 	if conf.Logger == nil {
-		conf.Logger = log.StandardLogger()
+		conf.Logger = slog.Default()
 	}
 	return Backoff{
 		Config: conf,
@@ -68,11 +151,23 @@ func NewInstance(fn func(ctx context.Context) error, conf Conf) Backoff {
 	}
 }
 
-func (b Backoff) _CallHealthCheck(ctx context.Context) {
+// _HealthResultChecker returns nil if health check is disabled
+func (b Backoff) _HealthResultChecker() HealthResultChecker {
+	if b.Config.HealthResultChecker != nil {
+		return b.Config.HealthResultChecker
+	}
+	if b.Config.HealthChecker != nil {
+		return b.Config.HealthChecker.Results(b.Clock())
+	}
+	return nil
+}
+
+// _CallHealthCheck sends *ErrorHealthCheckFailed to failed before canceling Fn
+func (b Backoff) _CallHealthCheck(ctx context.Context, checker HealthResultChecker, failed chan<- error) {
 	ctx, cancel := context.WithCancel(ctx)
-	resetWait, cancelFn := CtxResetWait{}.Must(ctx), CtxCancelFn{}.Must(ctx)
+	resetWait, cancelFn, observer := CtxResetWait{}.Must(ctx), CtxCancelFn{}.Must(ctx), CtxObserver{}.Must(ctx)
 
-	healthCheckChan := b.Config.HealthChecker(ctx)
+	results := checker(ctx)
 
 	go func() {
 		defer cancel()
@@ -81,17 +176,32 @@ func (b Backoff) _CallHealthCheck(ctx context.Context) {
 			select {
 			case <-ctx.Done():
 				return
-			case err := <-healthCheckChan:
-				if err != nil {
-					b.Config.Logger.Warnf("health check failed: %v", err)
-					cancelFn()
+			case result := <-results:
+				if ctx.Err() != nil {
+					// Fn returned or canceled by others
 					return
 				}
-				// call reset wait
-				select {
-				case <-ctx.Done():
+				observer.HealthCheckResult(ctx, result)
+				switch result.Status {
+				case HealthFail:
+					var healthErr *ErrorHealthCheckFailed
+					if err := result.AsError(); !errors.As(err, &healthErr) {
+						healthErr = &ErrorHealthCheckFailed{Err: err}
+					}
+					select {
+					case failed <- healthErr:
+					default:
+					}
+					observer.HealthCheckFailure(ctx, healthErr)
+					cancelFn(healthErr)
 					return
-				case resetWait <- struct{}{}:
+				case HealthPass:
+					// call reset wait
+					select {
+					case <-ctx.Done():
+						return
+					case resetWait <- struct{}{}:
+					}
 				}
 			}
 		}
@@ -99,13 +209,23 @@ func (b Backoff) _CallHealthCheck(ctx context.Context) {
 }
 
 func (b Backoff) _CallFn(ctx context.Context) <-chan error {
-	ctx, cancel := context.WithCancel(ctx)
+	ctx, cancel := context.WithCancelCause(ctx)
+	ctx = CtxCancelFn{}.Set(ctx, cancel)
+	cancelTimeout := context.CancelFunc(func() {})
+	if b.Config.AttemptTimeout > 0 {
+		ctx, cancelTimeout = context.WithTimeoutCause(ctx, b.Config.AttemptTimeout, ErrCauseAttemptTimeout)
+	}
 	// set capacity to 1 to avoid goroutine leak
 	errChan := make(chan error, 1)
-	ctx = CtxCancelFn{}.Set(ctx, cancel)
+	// failed receives the error replacing result of Fn canceled by health check or heartbeat
+	failed := make(chan error, 1)
+	if b.Config.HeartbeatTimeout > 0 {
+		ctx = b._WatchHeartbeat(ctx, failed)
+	}
 
 	go func() {
-		defer cancel()
+		defer cancel(nil)
+		defer cancelTimeout()
 
 		if !b.Config.DisableRecovery {
 			defer func() {
@@ -123,89 +243,249 @@ func (b Backoff) _CallFn(ctx context.Context) <-chan error {
 
 		// We need to wait for Fn returning an error anyway.
 		// If context is canceled by HealthCheck or parent,
-		// Fn should terminate waiting on itself.
-		errChan <- b.Fn(ctx)
+		// Fn should terminate waiting on itself, otherwise it's abandoned after AbandonAfter.
+		err := b.Fn(ctx)
+		select {
+		case err = <-failed:
+			// canceled by health check or heartbeat
+		default:
+		}
+		errChan <- err
 	}()
 
-	if b.Config.HealthChecker != nil {
-		b._CallHealthCheck(ctx)
+	if checker := b._HealthResultChecker(); checker != nil {
+		b._CallHealthCheck(ctx, checker, failed)
+	}
+	if b.Config.AbandonAfter > 0 {
+		return b._AbandonAfter(ctx, errChan)
 	}
 	return errChan
 }
 
+func (b Backoff) Clock() Clock {
+	if b.Config.Clock != nil {
+		return b.Config.Clock
+	}
+	return RealClock()
+}
+
+func (b Backoff) Strategy() Strategy {
+	if b.Config.Strategy != nil {
+		return b.Config.Strategy
+	}
+	return FactorStrategy{
+		ExponentFactor:   b.Config.ExponentFactor,
+		InterConstFactor: b.Config.InterConstFactor,
+		OuterConstFactor: b.Config.OuterConstFactor,
+	}
+}
+
 func (b Backoff) NextWait(wait time.Duration) time.Duration {
-	if wait < b.Config.MaxDuration {
-		wait = (wait+b.Config.InterConstFactor)<<b.Config.ExponentFactor + b.Config.OuterConstFactor
-		if wait > b.Config.MaxDuration {
-			return b.Config.MaxDuration
+	policy, _ := b._WaitPolicy(nil)
+	return policy.Next(wait)
+}
+
+// _WaitPolicy returns the policy calculating wait time after err,
+// true if it's HealthCheckWait and the wait time should be tracked separately.
+func (b Backoff) _WaitPolicy(err error) (WaitPolicy, bool) {
+	policy := WaitPolicy{
+		InitialDuration: b.Config.InitialDuration,
+		MaxDuration:     b.Config.MaxDuration,
+		Strategy:        b.Strategy(),
+	}
+	var healthErr *ErrorHealthCheckFailed
+	if b.Config.HealthCheckWait != nil && errors.As(err, &healthErr) {
+		if b.Config.HealthCheckWait.InitialDuration != 0 {
+			policy.InitialDuration = b.Config.HealthCheckWait.InitialDuration
+		}
+		if b.Config.HealthCheckWait.MaxDuration != 0 {
+			policy.MaxDuration = b.Config.HealthCheckWait.MaxDuration
+		}
+		if b.Config.HealthCheckWait.Strategy != nil {
+			policy.Strategy = b.Config.HealthCheckWait.Strategy
+		}
+		return policy, true
+	}
+	return policy, false
+}
+
+// _Permanent returns non-nil if err should not be retried.
+func (b Backoff) _Permanent(err error) *ErrorPermanent {
+	var permanent *ErrorPermanent
+	if errors.As(err, &permanent) {
+		if error(permanent) == err {
+			return permanent
 		}
-		return wait
+		return &ErrorPermanent{Err: err}
 	}
-	return b.Config.MaxDuration
+	if b.Config.RetryIf != nil && !b.Config.RetryIf(err) {
+		return &ErrorPermanent{Err: err}
+	}
+	return nil
 }
 
 func (b Backoff) Run(ctx context.Context) error {
-	logger := b.Config.Logger.WithContext(ctx)
+	_, err := b._Run(ctx, nil, b._MaxHistory(false))
+	return err
+}
 
-	retry := b.Config.MaxRetry
-	if retry != 0 {
-		// add 1 to ignore first try
-		retry += 1
+// RunWithResult is same as Run, but returns history of attempts, see Conf.MaxHistory.
+func (b Backoff) RunWithResult(ctx context.Context) (Result, error) {
+	return b._Run(ctx, nil, b._MaxHistory(true))
+}
+
+// _MaxHistory resolves default of Conf.MaxHistory, 0 means unlimited
+func (b Backoff) _MaxHistory(keepAll bool) uint {
+	switch {
+	case b.Config.MaxHistory != 0:
+		return b.Config.MaxHistory
+	case b.Config.MaxRetry != 0:
+		return b.Config.MaxRetry + 1
+	case keepAll:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// _Run is controlled by runner if not nil, see Start.
+// At most maxHistory attempts are kept, 0 means unlimited.
+func (b Backoff) _Run(ctx context.Context, runner *Runner, maxHistory uint) (Result, error) {
+	observer := Observers{NewLogObserver(b.Config.Logger, b.Config.MaxRetry), b.Config.Observer}
+	var retryNow <-chan struct{}
+	abandoned := new(atomic.Int64)
+	if runner != nil {
+		observer = append(observer, runner._Observer())
+		retryNow = runner.retryNow
+		abandoned = &runner.abandoned
 	}
+	ctx = CtxObserver{}.Set(ctx, observer)
+	ctx = CtxAbandoned{}.Set(ctx, abandoned)
+	retrier := b.NewRetrier(ctx)
+	clock := b.Clock()
 
-	wait := b.Config.InitialDuration
+	var result Result
+	var attempt AttemptInfo
+
+	giveUp := func(err error) (Result, error) {
+		observer.GiveUp(ctx, err)
+		return result, err
+	}
 
 	for {
+		if runner != nil {
+			if err := runner._WaitResume(ctx); err != nil {
+				return giveUp(err)
+			}
+		}
+
 		var resetWait = make(chan struct{})
 		ctx := CtxResetWait{}.Set(ctx, resetWait)
+		attempt.Attempt++
+		ctx = CtxAttemptInfo{}.Set(ctx, attempt)
 
-		errChan := b._CallFn(ctx)
+		if maxHistory != 0 && uint(len(result.Attempts)) >= maxHistory {
+			// copy to keep attempts passed out before unchanged
+			result.Attempts = slices.Clone(result.Attempts[uint(len(result.Attempts))-maxHistory+1:])
+		}
+		result.Attempts = append(result.Attempts, AttemptRecord{
+			Attempt: attempt.Attempt,
+			StartAt: clock.Now(),
+		})
+		record := &result.Attempts[len(result.Attempts)-1]
+		observer.AttemptStart(ctx, attempt)
+		var errChan <-chan error
+		if b.Config.HedgeDelay > 0 {
+			errChan = b._CallHedged(ctx)
+		} else {
+			errChan = b._CallFn(ctx)
+		}
 
 	waitFn:
 		var err error
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			record.Duration, record.Err = clock.Now().Sub(record.StartAt), ctx.Err()
+			return giveUp(ctx.Err())
 		case <-resetWait:
-			logger.Debugln("wait time reset by health check")
-			wait = b.Config.InitialDuration
+			if clock.Now().Sub(record.StartAt) < b.Config.ResetCooldown {
+				goto waitFn
+			}
+			retrier.Decay(1)
+			record.ResetReason = ResetReasonHealthCheck
+			observer.WaitReset(ctx, ResetReasonHealthCheck)
 			goto waitFn
 		case err = <-errChan:
-			if err == nil {
-				return nil
-			}
 			// break select
 		}
 
-		{
-			logger := logger
-			if retry != 0 {
-				logger = logger.WithFields(log.Fields{
-					"rest": retry - 1,
-				})
-			}
-			logger = logger.WithFields(log.Fields{
-				"wait": fmt.Sprintf("%.0fs", wait.Seconds()),
-			})
-			logger.Errorf("failed with error: %v", err)
-
+		record.Duration = clock.Now().Sub(record.StartAt)
+		if err == nil && record.Duration < b.Config.MinUptime {
+			err = &ErrorMinUptime{Uptime: record.Duration, MinUptime: b.Config.MinUptime}
 		}
+		record.Err = err
 
-		if retry != 0 {
-			retry -= 1
-			if retry == 0 {
-				logger.Errorln("max retry exceeded")
-				return &ErrorMaxRetryExceeded{LastError: err}
+		var state RetryState
+		var throttled bool
+		if err == nil {
+			if b.Config.RetryBudget != nil {
+				b.Config.RetryBudget.Success()
+			}
+			observer.Success(ctx, *record)
+			if !b.Config.Restart.OnSuccess() {
+				return result, nil
+			}
+			reset := record.Duration >= b.Config.ResetCooldown
+			state = retrier._Restart(result.Attempts, reset)
+			if reset {
+				record.ResetReason = ResetReasonSuccess
+				observer.WaitReset(ctx, ResetReasonSuccess)
+			}
+		} else {
+			observer.AttemptFailure(ctx, *record)
+			if !b.Config.Restart.OnFailure() {
+				return giveUp(err)
+			}
+			if permanent := b._Permanent(err); permanent != nil && !b.Config.Restart.OnPermanent() {
+				return giveUp(permanent)
+			}
+			if interval := b.Config.ResetUptimeInterval; interval > 0 &&
+				record.Duration >= max(interval, b.Config.ResetCooldown) {
+				retrier.Decay(uint(record.Duration / interval))
+				record.ResetReason = ResetReasonUptime
+				observer.WaitReset(ctx, ResetReasonUptime)
+			}
+			throttled = b.Config.RetryBudget != nil && !b.Config.RetryBudget.Failure()
+			if throttled && !b.Config.RetryBudgetWait {
+				return giveUp(&ErrorRetryBudgetExhausted{LastError: err})
+			}
+			if state, err = retrier._Next(err, result.Attempts); err != nil {
+				return giveUp(err)
 			}
 		}
 
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(wait):
-			// continue retry
+		record.Wait += state.Sleep
+		observer.WaitScheduled(ctx, state)
+		for sleep := state.Sleep; ; {
+			timer, stop := _NewTimer(clock, sleep)
+			select {
+			case <-ctx.Done():
+				stop()
+				return giveUp(ctx.Err())
+			case <-timer:
+				// continue retry
+			case <-retryNow:
+				// shared budget still decides when throttled
+				stop()
+			}
+			if !throttled || b.Config.RetryBudget.Allow() {
+				break
+			}
+			// poll until budget refilled by others, it's not counted as retry
+			sleep = max(state.Sleep, b.Config.InitialDuration)
+			record.Wait += sleep
 		}
 
-		wait = b.NextWait(wait)
+		attempt.Wait, attempt.LastError = record.Wait, record.Err
 	}
 }
@@ -2,10 +2,9 @@ package backoff
 
 import (
 	"context"
-	log "github.com/sirupsen/logrus"
+	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"io"
 	"testing"
 	"time"
 )
@@ -13,14 +12,12 @@ import (
 func TestBackoff_Recovery(t *testing.T) {
 	t.Parallel()
 
-	logger := log.New()
-	logger.SetOutput(io.Discard)
+	logger := _NewDiscardLogger()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	instance := NewInstance(func(ctx context.Context) error {
 		panic("fn panic")
-		return nil
 	}, Conf{
 		Logger:   logger,
 		MaxRetry: 1,
@@ -38,8 +35,7 @@ func TestBackoff_HealthCheck(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	logger := log.New()
-	logger.SetOutput(io.Discard)
+	logger := _NewDiscardLogger()
 
 	ping := make(chan struct{})
 	instance := NewInstance(func(ctx context.Context) error {
@@ -90,3 +86,96 @@ func TestBackoff_NextWait(t *testing.T) {
 
 	assert.Equal(t, instance.Config.MaxDuration, instance.NextWait(time.Minute*2))
 }
+
+func TestBackoff_NextWait_Strategy(t *testing.T) {
+	instance := New(func(ctx context.Context) error {
+		return nil
+	}, Conf{
+		MaxDuration: time.Second * 10,
+		Strategy:    ExponentialStrategy{Multiplier: 1.5},
+	})
+
+	assert.Equal(t, time.Millisecond*1500, instance.NextWait(instance.Config.InitialDuration))
+	assert.Equal(t, instance.Config.MaxDuration, instance.NextWait(time.Second*8))
+}
+
+func TestBackoff_Permanent(t *testing.T) {
+	t.Parallel()
+
+	logger := _NewDiscardLogger()
+
+	var count int
+	instance := NewInstance(func(ctx context.Context) error {
+		count++
+		return Permanent(assert.AnError)
+	}, Conf{
+		Logger:          logger,
+		InitialDuration: time.Millisecond,
+		MaxDuration:     time.Millisecond,
+	})
+
+	err := instance.Run(context.Background())
+	var errorPermanent *ErrorPermanent
+	require.ErrorAs(t, err, &errorPermanent)
+	assert.ErrorIs(t, err, assert.AnError, "permanent error should unwrap to cause")
+	assert.Equal(t, 1, count, "permanent error should not be retried")
+}
+
+func TestBackoff_RetryIf(t *testing.T) {
+	t.Parallel()
+
+	logger := _NewDiscardLogger()
+
+	var count int
+	instance := NewInstance(func(ctx context.Context) error {
+		count++
+		if count < 3 {
+			return context.DeadlineExceeded
+		}
+		return assert.AnError
+	}, Conf{
+		Logger:          logger,
+		InitialDuration: time.Millisecond,
+		MaxDuration:     time.Millisecond,
+		RetryIf: func(err error) bool {
+			return !errors.Is(err, assert.AnError)
+		},
+	})
+
+	err := instance.Run(context.Background())
+	var errorPermanent *ErrorPermanent
+	require.ErrorAs(t, err, &errorPermanent)
+	assert.ErrorIs(t, errorPermanent.Err, assert.AnError)
+	assert.Equal(t, 3, count, "retry if not work properly")
+}
+
+func TestBackoff_Permanent_Panic(t *testing.T) {
+	t.Parallel()
+
+	logger := _NewDiscardLogger()
+
+	instance := NewInstance(func(ctx context.Context) error {
+		panic(Permanent(assert.AnError))
+	}, Conf{
+		Logger:   logger,
+		MaxRetry: 1,
+	})
+	err := instance.Run(context.Background())
+	var errorPanic *ErrorPanic
+	require.ErrorAs(t, err, &errorPanic, "panic with permanent error should stop retrying")
+	assert.ErrorIs(t, err, assert.AnError)
+
+	var panicked []error
+	instance = NewInstance(func(ctx context.Context) error {
+		panic("fn panic")
+	}, Conf{
+		Logger: logger,
+		RetryIf: func(err error) bool {
+			panicked = append(panicked, err)
+			return false
+		},
+	})
+	require.ErrorAs(t, instance.Run(context.Background()), &errorPanic)
+	require.Len(t, panicked, 1)
+	assert.ErrorAs(t, panicked[0], &errorPanic, "ErrorPanic should be passed to RetryIf")
+}
@@ -0,0 +1,140 @@
+// Package backofftest provides helpers for testing code built on package backoff deterministically.
+package backofftest
+
+import (
+	"context"
+	"github.com/Mmx233/BackoffCli/backoff"
+	"sync"
+	"time"
+)
+
+var _ backoff.TimerClock = (*FakeClock)(nil)
+
+type _Waiter struct {
+	At time.Time
+	C  chan time.Time
+}
+
+// FakeClock only moves when Advance is called.
+type FakeClock struct {
+	lock    sync.Mutex
+	now     time.Time
+	waiters []_Waiter
+	// changed is closed and replaced when waiters changed
+	changed chan struct{}
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{
+		now:     now,
+		changed: make(chan struct{}),
+	}
+}
+
+func (c *FakeClock) _Notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, _Waiter{
+		At: c.now.Add(d),
+		C:  ch,
+	})
+	c._Notify()
+	return ch
+}
+
+// NewTimer works like After, stop removes the waiter if it has not fired.
+// Backoff uses it to release waiters it no longer needs.
+func (c *FakeClock) NewTimer(d time.Duration) (<-chan time.Time, func()) {
+	ch := c.After(d)
+	return ch, func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		for i, waiter := range c.waiters {
+			if waiter.C == ch {
+				c.waiters = append(c.waiters[:i:i], c.waiters[i+1:]...)
+				c._Notify()
+				return
+			}
+		}
+	}
+}
+
+// Advance moves clock forward and fires all expired waiters.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, waiter := range c.waiters {
+		if waiter.At.After(c.now) {
+			waiters = append(waiters, waiter)
+			continue
+		}
+		waiter.C <- c.now
+	}
+	c.waiters = waiters
+	c._Notify()
+}
+
+// Waiters counts pending After and NewTimer calls. Timers stopped are removed,
+// while waiters of After abandoned by caller are still counted until they are fired by Advance.
+func (c *FakeClock) Waiters() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.waiters)
+}
+
+// BlockUntil blocks until there are at least n pending waiters or context is done.
+func (c *FakeClock) BlockUntil(ctx context.Context, n int) error {
+	for {
+		c.lock.Lock()
+		count, changed := len(c.waiters), c.changed
+		c.lock.Unlock()
+		if count >= n {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// WaitAndAdvance waits for a pending waiter then advances clock to the nearest
+// one and fires it. The duration advanced is returned.
+func (c *FakeClock) WaitAndAdvance(ctx context.Context) (time.Duration, error) {
+	if err := c.BlockUntil(ctx, 1); err != nil {
+		return 0, err
+	}
+	c.lock.Lock()
+	next := c.waiters[0].At
+	for _, waiter := range c.waiters[1:] {
+		if waiter.At.Before(next) {
+			next = waiter.At
+		}
+	}
+	d := next.Sub(c.now)
+	c.lock.Unlock()
+
+	c.Advance(d)
+	return d, nil
+}
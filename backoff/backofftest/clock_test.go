@@ -0,0 +1,67 @@
+package backofftest
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	first, second := clock.After(time.Second), clock.After(time.Second*2)
+	assert.Equal(t, 2, clock.Waiters())
+
+	clock.Advance(time.Millisecond * 500)
+	assert.Empty(t, first, "fired too early")
+
+	clock.Advance(time.Millisecond * 500)
+	require.Len(t, first, 1)
+	assert.Equal(t, start.Add(time.Second), <-first)
+	assert.Empty(t, second)
+	assert.Equal(t, 1, clock.Waiters())
+
+	d, err := clock.WaitAndAdvance(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, d)
+	assert.Equal(t, start.Add(time.Second*2), <-second)
+	assert.Equal(t, start.Add(time.Second*2), clock.Now())
+
+	assert.Len(t, clock.After(0), 1, "non-positive duration should fire immediately")
+}
+
+func TestFakeClock_BlockUntil(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+
+	go func() {
+		<-clock.After(time.Second)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, clock.BlockUntil(ctx, 1))
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, clock.BlockUntil(ctx, 2), context.DeadlineExceeded)
+}
+
+func TestFakeClock_NewTimer(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+
+	_, stop := clock.NewTimer(time.Second)
+	timer, _ := clock.NewTimer(time.Second * 2)
+	assert.Equal(t, 2, clock.Waiters())
+
+	stop()
+	assert.Equal(t, 1, clock.Waiters(), "stopped timer should be removed")
+	stop()
+	assert.Equal(t, 1, clock.Waiters())
+
+	d, err := clock.WaitAndAdvance(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, time.Second*2, d, "should not advance to stopped timer")
+	assert.Len(t, timer, 1)
+}
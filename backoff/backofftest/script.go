@@ -0,0 +1,158 @@
+package backofftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Mmx233/BackoffCli/backoff"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// BlockUntilCanceled makes ScriptedFn wait for the context done and return ctx.Err()
+var BlockUntilCanceled = errors.New("block until canceled")
+
+// ErrScriptExhausted is returned as permanent error when Fn is called more times than scripted
+var ErrScriptExhausted = errors.New("script exhausted")
+
+// ScriptedFn returns scripted results in order and records every attempt.
+type ScriptedFn struct {
+	t       testing.TB
+	lock    sync.Mutex
+	results []error
+	calls   []backoff.AttemptInfo
+}
+
+func NewScriptedFn(t testing.TB, results ...error) *ScriptedFn {
+	return &ScriptedFn{
+		t:       t,
+		results: results,
+	}
+}
+
+func (s *ScriptedFn) Fn(ctx context.Context) error {
+	attempt, _ := backoff.AttemptFromContext(ctx)
+
+	s.lock.Lock()
+	index := len(s.calls)
+	s.calls = append(s.calls, attempt)
+	s.lock.Unlock()
+
+	if index >= len(s.results) {
+		s.t.Errorf("unexpected attempt %d, only %d scripted", index+1, len(s.results))
+		return backoff.Permanent(ErrScriptExhausted)
+	}
+	result := s.results[index]
+	if result == BlockUntilCanceled {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return result
+}
+
+// Calls returns AttemptInfo of every call
+func (s *ScriptedFn) Calls() []backoff.AttemptInfo {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return slices.Clone(s.calls)
+}
+
+// AssertWaits checks all scripted results are consumed and the time waited before each attempt.
+// The first wait should be 0.
+func (s *ScriptedFn) AssertWaits(waits ...time.Duration) bool {
+	s.t.Helper()
+	calls := s.Calls()
+	if len(calls) != len(s.results) {
+		s.t.Errorf("expected %d attempts, got %d", len(s.results), len(calls))
+		return false
+	}
+	actual := make([]time.Duration, len(calls))
+	for i, call := range calls {
+		if call.Attempt != uint(i+1) {
+			s.t.Errorf("expected attempt %d, got %d", i+1, call.Attempt)
+			return false
+		}
+		actual[i] = call.Wait
+	}
+	if !slices.Equal(waits, actual) {
+		s.t.Errorf("expected waits %v, got %v", waits, actual)
+		return false
+	}
+	return true
+}
+
+// NewScriptedHealthChecker sends scripts[n] to the nth call of HealthChecker in order.
+// Calls beyond scripts never send anything.
+func NewScriptedHealthChecker(scripts ...[]error) backoff.HealthChecker {
+	var lock sync.Mutex
+	var count int
+	return func(ctx context.Context) <-chan error {
+		lock.Lock()
+		index := count
+		count++
+		lock.Unlock()
+
+		if index >= len(scripts) {
+			return make(chan error)
+		}
+		errChan := make(chan error, len(scripts[index]))
+		for _, err := range scripts[index] {
+			errChan <- err
+		}
+		return errChan
+	}
+}
+
+// Recorder is an Observer recording retry lifecycle events in order, like "wait 1s".
+type Recorder struct {
+	lock   sync.Mutex
+	events []string
+}
+
+var _ backoff.Observer = (*Recorder)(nil)
+
+func (r *Recorder) _Add(format string, a ...any) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.events = append(r.events, fmt.Sprintf(format, a...))
+}
+
+func (r *Recorder) Events() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return slices.Clone(r.events)
+}
+
+func (r *Recorder) AttemptStart(_ context.Context, attempt backoff.AttemptInfo) {
+	r._Add("start %d", attempt.Attempt)
+}
+
+func (r *Recorder) AttemptFailure(_ context.Context, attempt backoff.AttemptRecord) {
+	r._Add("failure %d: %v", attempt.Attempt, attempt.Err)
+}
+
+func (r *Recorder) WaitScheduled(_ context.Context, state backoff.RetryState) {
+	r._Add("wait %s", state.Sleep)
+}
+
+func (r *Recorder) WaitReset(_ context.Context, reason backoff.ResetReason) {
+	r._Add("reset %s", reason)
+}
+
+func (r *Recorder) HealthCheckResult(_ context.Context, result backoff.HealthResult) {
+	r._Add("health check %s", result.Status)
+}
+
+func (r *Recorder) HealthCheckFailure(_ context.Context, err error) {
+	r._Add("health check failure: %v", err)
+}
+
+func (r *Recorder) GiveUp(_ context.Context, err error) {
+	r._Add("give up: %v", err)
+}
+
+func (r *Recorder) Success(_ context.Context, attempt backoff.AttemptRecord) {
+	r._Add("success %d", attempt.Attempt)
+}
@@ -0,0 +1,66 @@
+package backofftest
+
+import (
+	"context"
+	"github.com/Mmx233/BackoffCli/backoff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestScriptedFn(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	fn := NewScriptedFn(t, assert.AnError, assert.AnError, assert.AnError, nil)
+	var recorder Recorder
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- backoff.New(fn.Fn, backoff.Conf{
+			Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+			Clock:           clock,
+			Observer:        &recorder,
+			InitialDuration: time.Second,
+			MaxDuration:     time.Minute,
+		}).Run(ctx)
+	}()
+
+	for _, expected := range []time.Duration{time.Second, time.Second * 2, time.Second * 4} {
+		d, err := clock.WaitAndAdvance(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, expected, d)
+	}
+	require.NoError(t, <-errChan)
+
+	fn.AssertWaits(0, time.Second, time.Second*2, time.Second*4)
+	assert.Equal(t, []string{
+		"start 1", "failure 1: " + assert.AnError.Error(), "wait 1s",
+		"start 2", "failure 2: " + assert.AnError.Error(), "wait 2s",
+		"start 3", "failure 3: " + assert.AnError.Error(), "wait 4s",
+		"start 4", "success 4",
+	}, recorder.Events())
+}
+
+func TestScriptedHealthChecker(t *testing.T) {
+	t.Parallel()
+
+	fn := NewScriptedFn(t, BlockUntilCanceled, nil)
+	err := backoff.New(fn.Fn, backoff.Conf{
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		InitialDuration: time.Millisecond,
+		HealthChecker:   NewScriptedHealthChecker([]error{assert.AnError}),
+	}).Run(context.Background())
+	require.NoError(t, err)
+
+	calls := fn.Calls()
+	require.Len(t, calls, 2)
+	var healthErr *backoff.ErrorHealthCheckFailed
+	require.ErrorAs(t, calls[1].LastError, &healthErr, "first attempt should be canceled by health check")
+	assert.ErrorIs(t, healthErr, assert.AnError)
+}
@@ -0,0 +1,64 @@
+package backoff
+
+import (
+	"sync"
+)
+
+// RetryBudget throttles retries shared by many Backoff instances, in the style of gRPC retry throttling.
+// Every failed attempt takes a token and every success refills TokenRatio tokens,
+// retry goes ahead only if tokens left are more than half of MaxTokens.
+// It is safe for concurrent use.
+type RetryBudget struct {
+	lock       sync.Mutex
+	maxTokens  float64
+	tokenRatio float64
+	tokens     float64
+}
+
+// NewRetryBudget creates a full budget. maxTokens default 10, tokenRatio default 0.1.
+func NewRetryBudget(maxTokens uint, tokenRatio float64) *RetryBudget {
+	if maxTokens == 0 {
+		maxTokens = 10
+	}
+	if tokenRatio <= 0 {
+		tokenRatio = 0.1
+	}
+	return &RetryBudget{
+		maxTokens:  float64(maxTokens),
+		tokenRatio: tokenRatio,
+		tokens:     float64(maxTokens),
+	}
+}
+
+// Tokens returns tokens left
+func (b *RetryBudget) Tokens() float64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.tokens
+}
+
+// Allow reports whether retry can go ahead without taking a token
+func (b *RetryBudget) Allow() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b._Allow()
+}
+
+// Failure takes a token and reports whether retry can go ahead
+func (b *RetryBudget) Failure() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.tokens = max(b.tokens-1, 0)
+	return b._Allow()
+}
+
+// Success refills the budget
+func (b *RetryBudget) Success() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.tokens = min(b.tokens+b.tokenRatio, b.maxTokens)
+}
+
+func (b *RetryBudget) _Allow() bool {
+	return b.tokens > b.maxTokens/2
+}
@@ -0,0 +1,99 @@
+package backoff_test
+
+import (
+	"context"
+	"github.com/Mmx233/BackoffCli/backoff"
+	"github.com/Mmx233/BackoffCli/backoff/backofftest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRetryBudget(t *testing.T) {
+	budget := backoff.NewRetryBudget(4, 0.5)
+	assert.True(t, budget.Failure())
+	assert.False(t, budget.Failure(), "should be throttled once tokens not more than half")
+	assert.False(t, budget.Allow())
+	assert.EqualValues(t, 2, budget.Tokens())
+
+	budget.Success()
+	assert.True(t, budget.Allow())
+	for range 10 {
+		budget.Success()
+	}
+	assert.EqualValues(t, 4, budget.Tokens(), "should not exceed max tokens")
+}
+
+func TestBackoff_RetryBudget(t *testing.T) {
+	t.Parallel()
+
+	budget := backoff.NewRetryBudget(4, 1)
+	var count int
+	err := backoff.New(func(ctx context.Context) error {
+		count++
+		return assert.AnError
+	}, backoff.Conf{
+		Logger:          backoff.NewDiscardLogger(),
+		InitialDuration: time.Millisecond,
+		RetryBudget:     budget,
+	}).Run(context.Background())
+	var errorBudget *backoff.ErrorRetryBudgetExhausted
+	require.ErrorAs(t, err, &errorBudget)
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, 2, count)
+
+	// shared budget throttles other instances immediately
+	count = 0
+	err = backoff.New(func(ctx context.Context) error {
+		count++
+		return assert.AnError
+	}, backoff.Conf{
+		Logger:      backoff.NewDiscardLogger(),
+		RetryBudget: budget,
+	}).Run(context.Background())
+	assert.ErrorAs(t, err, &errorBudget)
+	assert.Equal(t, 1, count)
+}
+
+func TestBackoff_RetryBudgetWait(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	clock := backofftest.NewFakeClock(time.Unix(0, 0))
+	budget := backoff.NewRetryBudget(2, 1)
+	fn := backofftest.NewScriptedFn(t, assert.AnError, nil)
+	var recorder backofftest.Recorder
+	runner := backoff.New(fn.Fn, backoff.Conf{
+		Logger:          backoff.NewDiscardLogger(),
+		InitialDuration: time.Second,
+		MaxDuration:     time.Second,
+		MaxRetry:        1,
+		Clock:           clock,
+		Observer:        &recorder,
+		RetryBudget:     budget,
+		RetryBudgetWait: true,
+	}).Start(ctx)
+
+	for range 3 {
+		_, err := clock.WaitAndAdvance(ctx)
+		require.NoError(t, err)
+	}
+	// refilled by others while waiting
+	require.NoError(t, clock.BlockUntil(ctx, 1))
+	budget.Success()
+	_, err := clock.WaitAndAdvance(ctx)
+	require.NoError(t, err)
+
+	_, err = runner.Wait()
+	require.NoError(t, err, "waiting for budget should not count as retry")
+	fn.AssertWaits(0, time.Second*4)
+	assert.Equal(t, []string{
+		"start 1",
+		"failure 1: " + assert.AnError.Error(),
+		"wait 1s",
+		"start 2",
+		"success 2",
+	}, recorder.Events())
+}
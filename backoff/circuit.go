@@ -0,0 +1,214 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type CircuitState string
+
+const (
+	// CircuitClosed lets all calls pass
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen fails calls fast with ErrorCircuitOpen until cool-down ends
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen runs a single trial, success closes the circuit and failure opens it again
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+type CircuitBreakerConf struct {
+	// Backoff calculates cool-down of open state, growing every time the trial failed.
+	// Only InitialDuration, MaxDuration, Strategy, factors, Jitter, Rand and Clock are used.
+	Backoff Conf
+	// FailureThreshold is the count of consecutive failures opening the circuit, default 5
+	FailureThreshold uint
+	// Trial is called in half-open state, default the first call after cool-down is the trial.
+	// Use NewHealthCheckerProbe to trial with a HealthChecker.
+	Trial ProbeHealthCheckFn
+	// OnStateChange is called after state changed
+	OnStateChange func(from, to CircuitState)
+}
+
+// CircuitBreaker stops calling a failing dependency for a while. Errors of the context
+// passed to Call are not counted as failures. It is safe for concurrent use.
+type CircuitBreaker struct {
+	conf    CircuitBreakerConf
+	backoff Backoff
+
+	lock      sync.Mutex
+	state     CircuitState
+	failures  uint
+	wait      time.Duration
+	sleep     time.Duration
+	openUntil time.Time
+	trial     bool
+}
+
+func NewCircuitBreaker(conf CircuitBreakerConf) *CircuitBreaker {
+	if conf.FailureThreshold == 0 {
+		conf.FailureThreshold = 5
+	}
+	b := New(nil, conf.Backoff)
+	return &CircuitBreaker{
+		conf:    conf,
+		backoff: b,
+		state:   CircuitClosed,
+		wait:    b.Config.InitialDuration,
+	}
+}
+
+// State returns current state, open circuit after cool-down is reported as half-open
+func (c *CircuitBreaker) State() CircuitState {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.state == CircuitOpen && !c.backoff.Clock().Now().Before(c.openUntil) {
+		return CircuitHalfOpen
+	}
+	return c.state
+}
+
+// Reset closes the circuit and resets cool-down
+func (c *CircuitBreaker) Reset() {
+	c.lock.Lock()
+	from := c.state
+	c._Close()
+	c.lock.Unlock()
+	c._Notify(from, CircuitClosed)
+}
+
+// Call fn if circuit allows, otherwise returns *ErrorCircuitOpen
+func (c *CircuitBreaker) Call(ctx context.Context, fn Fn) error {
+	trial, err := c._Allow(ctx)
+	if err != nil {
+		return err
+	}
+	if trial && c.conf.Trial != nil {
+		if err = c._Call(ctx, true, Fn(c.conf.Trial)); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return c._OpenError()
+		}
+		trial = false
+	}
+	return c._Call(ctx, trial, fn)
+}
+
+// _Call calls fn and records the result, panic is recorded as failure then re-panicked
+func (c *CircuitBreaker) _Call(ctx context.Context, trial bool, fn Fn) (err error) {
+	defer func() {
+		if reason := recover(); reason != nil {
+			c._Done(ctx, trial, &ErrorPanic{Reason: reason})
+			panic(reason)
+		}
+		c._Done(ctx, trial, err)
+	}()
+	return fn(ctx)
+}
+
+// Wrap fn to be called through the circuit breaker, e.g. as Fn of Backoff.
+// ErrorCircuitOpen implements RetryAfterError, so Run waits until cool-down ends.
+func (c *CircuitBreaker) Wrap(fn Fn) Fn {
+	return func(ctx context.Context) error {
+		return c.Call(ctx, fn)
+	}
+}
+
+// _Allow returns true if the call is the trial of half-open state
+func (c *CircuitBreaker) _Allow(ctx context.Context) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	c.lock.Lock()
+	var from CircuitState
+	defer func() {
+		c.lock.Unlock()
+		if from != "" {
+			c._Notify(from, CircuitHalfOpen)
+		}
+	}()
+
+	switch c.state {
+	case CircuitClosed:
+		return false, nil
+	case CircuitOpen:
+		if c.backoff.Clock().Now().Before(c.openUntil) {
+			return false, c._OpenErrorLocked()
+		}
+		from, c.state = c.state, CircuitHalfOpen
+	}
+	if c.trial {
+		return false, c._OpenErrorLocked()
+	}
+	c.trial = true
+	return true, nil
+}
+
+// _Done records result of the call
+func (c *CircuitBreaker) _Done(ctx context.Context, trial bool, err error) {
+	c.lock.Lock()
+	from := c.state
+	if err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		// canceled by caller, not a failure of the dependency
+		if trial {
+			c.trial = false
+		}
+		c.lock.Unlock()
+		return
+	}
+
+	switch {
+	case trial:
+		c.trial = false
+		if err == nil {
+			c._Close()
+		} else {
+			c.wait = c.backoff.NextWait(c.wait)
+			c._Open()
+		}
+	case c.state != CircuitClosed:
+		// calls started before circuit opened
+	case err == nil:
+		c.failures = 0
+	default:
+		c.failures++
+		if c.failures >= c.conf.FailureThreshold {
+			c._Open()
+		}
+	}
+	to := c.state
+	c.lock.Unlock()
+	c._Notify(from, to)
+}
+
+func (c *CircuitBreaker) _Open() {
+	c.state = CircuitOpen
+	c.sleep = c.backoff.JitterWait(c.wait, c.sleep)
+	c.openUntil = c.backoff.Clock().Now().Add(c.sleep)
+}
+
+func (c *CircuitBreaker) _Close() {
+	c.state, c.failures, c.trial = CircuitClosed, 0, false
+	c.wait, c.sleep = c.backoff.Config.InitialDuration, 0
+}
+
+func (c *CircuitBreaker) _OpenError() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c._OpenErrorLocked()
+}
+
+func (c *CircuitBreaker) _OpenErrorLocked() error {
+	return &ErrorCircuitOpen{
+		State:           c.state,
+		RetryAfterDelay: max(c.openUntil.Sub(c.backoff.Clock().Now()), 0),
+	}
+}
+
+func (c *CircuitBreaker) _Notify(from, to CircuitState) {
+	if from != to && c.conf.OnStateChange != nil {
+		c.conf.OnStateChange(from, to)
+	}
+}
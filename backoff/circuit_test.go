@@ -0,0 +1,186 @@
+package backoff_test
+
+import (
+	"context"
+	"github.com/Mmx233/BackoffCli/backoff"
+	"github.com/Mmx233/BackoffCli/backoff/backofftest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func _NewTestCircuitBreaker(trial backoff.ProbeHealthCheckFn) (*backoff.CircuitBreaker, *backofftest.FakeClock, *[]backoff.CircuitState) {
+	clock := backofftest.NewFakeClock(time.Unix(0, 0))
+	var states []backoff.CircuitState
+	return backoff.NewCircuitBreaker(backoff.CircuitBreakerConf{
+		Backoff: backoff.Conf{
+			InitialDuration: time.Second,
+			MaxDuration:     time.Second * 3,
+			Clock:           clock,
+		},
+		FailureThreshold: 2,
+		Trial:            trial,
+		OnStateChange: func(from, to backoff.CircuitState) {
+			states = append(states, to)
+		},
+	}), clock, &states
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	breaker, clock, states := _NewTestCircuitBreaker(nil)
+	ctx := context.Background()
+	fail := func(ctx context.Context) error { return assert.AnError }
+	var called int
+	succeed := func(ctx context.Context) error {
+		called++
+		return nil
+	}
+
+	assert.ErrorIs(t, breaker.Call(ctx, fail), assert.AnError)
+	assert.Equal(t, backoff.CircuitClosed, breaker.State())
+	assert.ErrorIs(t, breaker.Call(ctx, fail), assert.AnError)
+	assert.Equal(t, backoff.CircuitOpen, breaker.State())
+
+	var errorCircuitOpen *backoff.ErrorCircuitOpen
+	require.ErrorAs(t, breaker.Call(ctx, succeed), &errorCircuitOpen)
+	assert.Equal(t, time.Second, errorCircuitOpen.RetryAfter())
+	assert.Zero(t, called, "should fail fast while open")
+
+	// failed trial opens circuit with longer cool-down
+	clock.Advance(time.Second)
+	assert.Equal(t, backoff.CircuitHalfOpen, breaker.State())
+	assert.ErrorIs(t, breaker.Call(ctx, fail), assert.AnError)
+	require.ErrorAs(t, breaker.Call(ctx, succeed), &errorCircuitOpen)
+	assert.Equal(t, time.Second*2, errorCircuitOpen.RetryAfter())
+
+	clock.Advance(time.Second * 2)
+	assert.NoError(t, breaker.Call(ctx, succeed))
+	assert.Equal(t, backoff.CircuitClosed, breaker.State())
+	assert.Equal(t, 1, called)
+
+	assert.Equal(t, []backoff.CircuitState{
+		backoff.CircuitOpen, backoff.CircuitHalfOpen, backoff.CircuitOpen, backoff.CircuitHalfOpen, backoff.CircuitClosed,
+	}, *states)
+
+	// cool-down is reset after closed
+	_ = breaker.Call(ctx, fail)
+	_ = breaker.Call(ctx, fail)
+	require.ErrorAs(t, breaker.Call(ctx, succeed), &errorCircuitOpen)
+	assert.Equal(t, time.Second, errorCircuitOpen.RetryAfter())
+}
+
+func TestCircuitBreaker_Trial(t *testing.T) {
+	var trialErr error
+	breaker, clock, _ := _NewTestCircuitBreaker(backoff.NewHealthCheckerProbe(func(ctx context.Context) <-chan error {
+		errChan := make(chan error, 1)
+		errChan <- trialErr
+		return errChan
+	}))
+	ctx := context.Background()
+	var called int
+	fn := func(ctx context.Context) error {
+		called++
+		return assert.AnError
+	}
+	_ = breaker.Call(ctx, fn)
+	_ = breaker.Call(ctx, fn)
+
+	clock.Advance(time.Second)
+	trialErr = assert.AnError
+	var errorCircuitOpen *backoff.ErrorCircuitOpen
+	require.ErrorAs(t, breaker.Call(ctx, fn), &errorCircuitOpen, "failed trial should not call fn")
+	assert.Equal(t, time.Second*2, errorCircuitOpen.RetryAfter())
+	assert.Equal(t, 2, called)
+
+	clock.Advance(time.Second * 2)
+	trialErr = nil
+	assert.ErrorIs(t, breaker.Call(ctx, fn), assert.AnError)
+	assert.Equal(t, 3, called)
+	assert.Equal(t, backoff.CircuitClosed, breaker.State(), "single failure after trial should not open")
+}
+
+func TestCircuitBreaker_HalfOpenSingleTrial(t *testing.T) {
+	breaker, clock, _ := _NewTestCircuitBreaker(nil)
+	ctx := context.Background()
+	_ = breaker.Call(ctx, func(ctx context.Context) error { return assert.AnError })
+	_ = breaker.Call(ctx, func(ctx context.Context) error { return assert.AnError })
+	clock.Advance(time.Second)
+
+	assert.NoError(t, breaker.Call(ctx, func(ctx context.Context) error {
+		var errorCircuitOpen *backoff.ErrorCircuitOpen
+		require.ErrorAs(t, breaker.Call(ctx, func(ctx context.Context) error { return nil }), &errorCircuitOpen,
+			"only one trial should run in half-open state")
+		assert.Equal(t, backoff.CircuitHalfOpen, errorCircuitOpen.State)
+		return nil
+	}))
+}
+
+func TestCircuitBreaker_Canceled(t *testing.T) {
+	breaker, _, _ := _NewTestCircuitBreaker(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for range 3 {
+		assert.ErrorIs(t, breaker.Call(ctx, func(ctx context.Context) error { return ctx.Err() }), context.Canceled)
+	}
+	assert.Equal(t, backoff.CircuitClosed, breaker.State())
+}
+
+func TestCircuitBreaker_Wrap(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := backofftest.NewFakeClock(time.Unix(0, 0))
+	_AutoAdvance(ctx, clock)
+
+	breaker := backoff.NewCircuitBreaker(backoff.CircuitBreakerConf{
+		Backoff:          backoff.Conf{InitialDuration: time.Minute, Clock: clock},
+		FailureThreshold: 1,
+	})
+	var lock sync.Mutex
+	var calledAt []time.Time
+	err := backoff.New(breaker.Wrap(func(ctx context.Context) error {
+		lock.Lock()
+		defer lock.Unlock()
+		calledAt = append(calledAt, clock.Now())
+		if len(calledAt) < 2 {
+			return assert.AnError
+		}
+		return nil
+	}), backoff.Conf{
+		Logger:          backoff.NewDiscardLogger(),
+		InitialDuration: time.Second,
+		MaxRetry:        5,
+		Clock:           clock,
+	}).Run(ctx)
+	require.NoError(t, err)
+	require.Len(t, calledAt, 2)
+	assert.Equal(t, time.Minute, calledAt[1].Sub(calledAt[0]), "should wait for cool-down")
+}
+
+func TestCircuitBreaker_Panic(t *testing.T) {
+	panicTrial := true
+	breaker, clock, _ := _NewTestCircuitBreaker(func(ctx context.Context) error {
+		if panicTrial {
+			panic("trial panic")
+		}
+		return nil
+	})
+	ctx := context.Background()
+	fail := func(ctx context.Context) error { panic("test panic") }
+	assert.PanicsWithValue(t, "test panic", func() { _ = breaker.Call(ctx, fail) })
+	assert.PanicsWithValue(t, "test panic", func() { _ = breaker.Call(ctx, fail) })
+	assert.Equal(t, backoff.CircuitOpen, breaker.State(), "panic should count as failure")
+
+	clock.Advance(time.Second)
+	assert.PanicsWithValue(t, "trial panic", func() { _ = breaker.Call(ctx, fail) })
+	assert.Equal(t, backoff.CircuitOpen, breaker.State(), "panicking trial should open circuit again")
+
+	clock.Advance(time.Second * 2)
+	panicTrial = false
+	assert.NoError(t, breaker.Call(ctx, func(ctx context.Context) error { return nil }),
+		"panicking trial should not block later trials")
+	assert.Equal(t, backoff.CircuitClosed, breaker.State())
+}
@@ -0,0 +1,50 @@
+package backoff
+
+import (
+	"time"
+)
+
+// Clock is the time source of backoff, replace it with a fake clock in tests.
+// See package backofftest.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// TimerClock is a Clock able to release waiters abandoned by callers, e.g. backofftest.FakeClock.
+// Backoff stops waiters through it once they are no longer needed.
+type TimerClock interface {
+	Clock
+	// NewTimer works like After, stop releases the waiter if it has not fired
+	NewTimer(d time.Duration) (c <-chan time.Time, stop func())
+}
+
+// _NewTimer uses TimerClock if implemented, otherwise stop does nothing
+func _NewTimer(clock Clock, d time.Duration) (<-chan time.Time, func()) {
+	if timerClock, ok := clock.(TimerClock); ok {
+		return timerClock.NewTimer(d)
+	}
+	return clock.After(d), func() {}
+}
+
+type _RealClock struct{}
+
+func (_RealClock) Now() time.Time {
+	return time.Now()
+}
+
+func (_RealClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (_RealClock) NewTimer(d time.Duration) (<-chan time.Time, func()) {
+	timer := time.NewTimer(d)
+	return timer.C, func() {
+		timer.Stop()
+	}
+}
+
+// RealClock returns the clock of time package
+func RealClock() Clock {
+	return _RealClock{}
+}
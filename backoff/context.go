@@ -2,9 +2,45 @@ package backoff
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync/atomic"
+	"time"
 )
 
+// Causes of canceling the context passed to Fn, see CancelCause.
+var (
+	// ErrCauseShutdown means the context passed to Run is done
+	ErrCauseShutdown = errors.New("shutdown")
+	// ErrCauseHealthCheck means HealthChecker failed, the cause is *ErrorHealthCheckFailed
+	ErrCauseHealthCheck = errors.New("health check failed")
+	// ErrCauseAttemptTimeout means Conf.AttemptTimeout exceeded
+	ErrCauseAttemptTimeout = errors.New("attempt timeout")
+	// ErrCauseHeartbeatTimeout means Fn missed heartbeats, the cause is *ErrorHeartbeatTimeout
+	ErrCauseHeartbeatTimeout = errors.New("heartbeat timeout")
+	// ErrCauseHedgeLost means another hedged call of the attempt succeeded first
+	ErrCauseHedgeLost = errors.New("hedged call lost")
+)
+
+// CancelCause tells why the context passed to Fn is done, nil if not done.
+// Causes set by backoff are returned as is, otherwise the cause of parent wrapped with ErrCauseShutdown.
+//
+//	if errors.Is(backoff.CancelCause(ctx), backoff.ErrCauseShutdown) {
+//		flush()
+//	}
+func CancelCause(ctx context.Context) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+	cause := context.Cause(ctx)
+	for _, known := range [...]error{ErrCauseShutdown, ErrCauseHealthCheck, ErrCauseAttemptTimeout, ErrCauseHeartbeatTimeout, ErrCauseHedgeLost} {
+		if errors.Is(cause, known) {
+			return cause
+		}
+	}
+	return fmt.Errorf("%w: %w", ErrCauseShutdown, cause)
+}
+
 type CtxStructKey[Key, Value any] struct{}
 
 func (CtxStructKey[Key, Value]) Set(ctx context.Context, value Value) context.Context {
@@ -36,5 +72,34 @@ type CtxResetWait struct {
 	CtxStructKey[CtxResetWait, chan struct{}]
 }
 type CtxCancelFn struct {
-	CtxStructKey[CtxCancelFn, context.CancelFunc]
+	CtxStructKey[CtxCancelFn, context.CancelCauseFunc]
+}
+type CtxObserver struct {
+	CtxStructKey[CtxObserver, Observer]
+}
+
+// CtxAbandoned is the count of abandoned Fn calls of a Run still running
+type CtxAbandoned struct {
+	CtxStructKey[CtxAbandoned, *atomic.Int64]
+}
+
+// AttemptInfo describes the current call of Fn
+type AttemptInfo struct {
+	// Attempt counts calls of Fn since Run started, starts from 1
+	Attempt uint
+	// Hedge counts hedged calls of the same attempt, 0 for the first call
+	Hedge uint
+	// Wait is the time slept before this attempt
+	Wait time.Duration
+	// LastError returned by the previous attempt, nil for the first attempt or after success
+	LastError error
+}
+
+type CtxAttemptInfo struct {
+	CtxStructKey[CtxAttemptInfo, AttemptInfo]
+}
+
+// AttemptFromContext reads AttemptInfo from the context passed to Fn
+func AttemptFromContext(ctx context.Context) (AttemptInfo, bool) {
+	return CtxAttemptInfo{}.Get(ctx)
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 const value = "value"
@@ -47,3 +48,98 @@ func TestCtxStructKey_Write(t *testing.T) {
 
 	assert.Equal(t, value, (Key{}).Must(ctx), "CtxStructKey.Set not work properly")
 }
+
+func TestAttemptFromContext(t *testing.T) {
+	t.Parallel()
+
+	logger := _NewDiscardLogger()
+
+	var attempts []AttemptInfo
+	err := New(func(ctx context.Context) error {
+		attempt, ok := AttemptFromContext(ctx)
+		require.True(t, ok, "attempt info not found in context")
+		attempts = append(attempts, attempt)
+		if attempt.Attempt == 3 {
+			return nil
+		}
+		return assert.AnError
+	}, Conf{
+		Logger:          logger,
+		InitialDuration: time.Millisecond,
+		MaxDuration:     time.Millisecond * 10,
+	}).Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []AttemptInfo{
+		{Attempt: 1},
+		{Attempt: 2, Wait: time.Millisecond, LastError: assert.AnError},
+		{Attempt: 3, Wait: time.Millisecond * 2, LastError: assert.AnError},
+	}, attempts)
+
+	_, ok := AttemptFromContext(context.Background())
+	assert.False(t, ok)
+}
+
+func _RunForCancelCause(t *testing.T, ctx context.Context, conf Conf) error {
+	t.Helper()
+	causes := make(chan error, 1)
+	conf.Logger = _NewDiscardLogger()
+	conf.Restart = RestartNever
+	_ = New(func(ctx context.Context) error {
+		<-ctx.Done()
+		causes <- CancelCause(ctx)
+		return ctx.Err()
+	}, conf).Run(ctx)
+	select {
+	case cause := <-causes:
+		return cause
+	case <-time.After(time.Second):
+		t.Fatal("Fn not canceled")
+		return nil
+	}
+}
+
+func TestCancelCause(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, CancelCause(context.Background()), "should be nil before done")
+
+	cause := _RunForCancelCause(t, context.Background(), Conf{AttemptTimeout: time.Millisecond})
+	assert.ErrorIs(t, cause, ErrCauseAttemptTimeout)
+
+	cause = _RunForCancelCause(t, context.Background(), Conf{
+		HealthChecker: func(ctx context.Context) <-chan error {
+			errChan := make(chan error, 1)
+			errChan <- assert.AnError
+			return errChan
+		},
+	})
+	assert.ErrorIs(t, cause, ErrCauseHealthCheck)
+	var healthErr *ErrorHealthCheckFailed
+	assert.ErrorAs(t, cause, &healthErr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	cause = _RunForCancelCause(t, ctx, Conf{})
+	assert.ErrorIs(t, cause, ErrCauseShutdown)
+	assert.ErrorIs(t, cause, context.DeadlineExceeded, "should wrap cause of parent")
+}
+
+func TestCancelCause_Hedge(t *testing.T) {
+	t.Parallel()
+
+	causes := make(chan error, 1)
+	_, err := Do(context.Background(), Conf{
+		Logger:     _NewDiscardLogger(),
+		HedgeDelay: time.Millisecond,
+	}, func(ctx context.Context) (struct{}, error) {
+		if attempt, _ := AttemptFromContext(ctx); attempt.Hedge == 0 {
+			<-ctx.Done()
+			causes <- CancelCause(ctx)
+			return struct{}{}, ctx.Err()
+		}
+		return struct{}{}, nil
+	})
+	require.NoError(t, err)
+	assert.ErrorIs(t, <-causes, ErrCauseHedgeLost)
+}
@@ -0,0 +1,74 @@
+package backoff
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// Do calls fn with backoff like Backoff.Run and returns the value of the successful call.
+// Do returns once fn succeeded, so Conf.Restart restarting on success is treated as RestartOnFailure.
+// With Conf.HedgeDelay, value of the first successful hedged call is returned.
+// Successful values not returned, e.g. of hedged calls lost or calls abandoned, are closed
+// if they implement io.Closer, use DoWithDiscard to release other values.
+func Do[T any](ctx context.Context, conf Conf, fn func(ctx context.Context) (T, error)) (T, error) {
+	return DoWithDiscard(ctx, conf, fn, _CloseValue[T])
+}
+
+// DoWithDiscard is same as Do, but calls discard with every successful value not returned,
+// it may be called after DoWithDiscard returned. Nil discard drops values silently.
+func DoWithDiscard[T any](ctx context.Context, conf Conf, fn func(ctx context.Context) (T, error), discard func(T)) (T, error) {
+	if conf.Restart.OnSuccess() {
+		conf.Restart = RestartOnFailure
+	}
+	if discard == nil {
+		discard = func(T) {}
+	}
+
+	var lock sync.Mutex
+	var value T
+	// valueOf is the attempt value belongs to, only the first success of hedged calls is taken
+	var valueOf uint
+	// returned is set once Run returned, values of calls not terminated in time are dropped
+	var returned bool
+
+	err := New(func(ctx context.Context) error {
+		result, err := fn(ctx)
+		if err != nil {
+			return err
+		}
+		attempt, _ := AttemptFromContext(ctx)
+		lock.Lock()
+		dropped, drop := result, true
+		if !returned && attempt.Attempt > valueOf {
+			// value taken before comes from an abandoned call of earlier attempt
+			dropped, drop = value, valueOf != 0
+			value, valueOf = result, attempt.Attempt
+		}
+		lock.Unlock()
+		if drop {
+			discard(dropped)
+		}
+		return nil
+	}, conf).Run(ctx)
+
+	lock.Lock()
+	returned = true
+	taken := valueOf != 0
+	lock.Unlock()
+	if err != nil {
+		if taken {
+			discard(value)
+		}
+		var zero T
+		return zero, err
+	}
+	return value, nil
+}
+
+// _CloseValue closes value if it implements io.Closer
+func _CloseValue[T any](value T) {
+	if closer, ok := any(value).(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
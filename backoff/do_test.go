@@ -0,0 +1,142 @@
+package backoff_test
+
+import (
+	"context"
+	"github.com/Mmx233/BackoffCli/backoff"
+	"github.com/Mmx233/BackoffCli/backoff/backofftest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+
+	var count int
+	value, err := backoff.Do(context.Background(), backoff.Conf{
+		Logger:          backoff.NewDiscardLogger(),
+		InitialDuration: time.Millisecond,
+		Restart:         backoff.RestartAlways,
+	}, func(ctx context.Context) (int, error) {
+		count++
+		if count < 3 {
+			return count, assert.AnError
+		}
+		return count, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, value)
+	assert.Equal(t, 3, count, "should return once succeeded")
+}
+
+func TestDo_Error(t *testing.T) {
+	t.Parallel()
+
+	value, err := backoff.Do(context.Background(), backoff.Conf{
+		Logger:          backoff.NewDiscardLogger(),
+		InitialDuration: time.Millisecond,
+	}, func(ctx context.Context) (string, error) {
+		return "ignored", backoff.Permanent(assert.AnError)
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Empty(t, value, "should return zero value on error")
+}
+
+func TestDo_Panic(t *testing.T) {
+	t.Parallel()
+
+	_, err := backoff.Do(context.Background(), backoff.Conf{
+		Logger:  backoff.NewDiscardLogger(),
+		Restart: backoff.RestartNever,
+	}, func(ctx context.Context) (*int, error) {
+		panic("test panic")
+	})
+	var errorPanic *backoff.ErrorPanic
+	require.ErrorAs(t, err, &errorPanic)
+	assert.Equal(t, "test panic", errorPanic.Reason)
+}
+
+func TestDo_Canceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	value, err := backoff.Do(ctx, backoff.Conf{
+		Logger: backoff.NewDiscardLogger(),
+	}, func(ctx context.Context) (int, error) {
+		cancel()
+		// ignore cancellation and succeed after Do returned
+		<-release
+		return 1, nil
+	})
+	close(release)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, value)
+}
+
+func TestDoWithDiscard(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	clock := backofftest.NewFakeClock(time.Unix(0, 0))
+	go func() {
+		_, _ = clock.WaitAndAdvance(ctx)
+	}()
+
+	discarded := make(chan uint, 2)
+	value, err := backoff.DoWithDiscard(ctx, backoff.Conf{
+		Logger:     backoff.NewDiscardLogger(),
+		HedgeDelay: time.Second,
+		Clock:      clock,
+	}, func(ctx context.Context) (uint, error) {
+		attempt, _ := backoff.AttemptFromContext(ctx)
+		if attempt.Hedge == 0 {
+			// lost the race but still succeeded
+			<-ctx.Done()
+		}
+		return attempt.Hedge, nil
+	}, func(value uint) {
+		discarded <- value
+	})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, value)
+	select {
+	case value := <-discarded:
+		assert.EqualValues(t, 0, value, "value of lost hedged call should be discarded")
+	case <-ctx.Done():
+		t.Fatal("value not discarded")
+	}
+	assert.Empty(t, discarded)
+}
+
+type _CountCloser struct {
+	closed *atomic.Int32
+}
+
+func (c _CountCloser) Close() error {
+	c.closed.Add(1)
+	return nil
+}
+
+func TestDo_CloseDiscarded(t *testing.T) {
+	t.Parallel()
+
+	var closed atomic.Int32
+	release := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := backoff.Do(ctx, backoff.Conf{
+		Logger: backoff.NewDiscardLogger(),
+	}, func(ctx context.Context) (_CountCloser, error) {
+		cancel()
+		<-release
+		return _CountCloser{closed: &closed}, nil
+	})
+	close(release)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Eventually(t, func() bool {
+		return closed.Load() == 1
+	}, time.Second, time.Millisecond, "value returned after Do should be closed")
+}
@@ -1,6 +1,13 @@
 package backoff
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type ErrorAlreadyRunning struct{}
 
@@ -17,22 +24,244 @@ func (e ErrorPanic) Error() string {
 	return fmt.Sprintf("panic: %v\n%s", e.Reason, e.Stack)
 }
 
+// Unwrap returns Reason if panic with an error
+func (e ErrorPanic) Unwrap() error {
+	err, _ := e.Reason.(error)
+	return err
+}
+
+// ErrorPermanent stops retrying immediately
+type ErrorPermanent struct {
+	Err error
+}
+
+// Permanent wraps err to tell backoff not to retry, nil err returns nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ErrorPermanent{Err: err}
+}
+
+func (e ErrorPermanent) Error() string {
+	return fmt.Sprintf("permanent error: %v", e.Err)
+}
+
+func (e ErrorPermanent) Unwrap() error {
+	return e.Err
+}
+
 type ErrorMaxRetryExceeded struct {
 	LastError error
+	// Attempts contains attempts since Run started, limited by Conf.MaxHistory
+	Attempts []AttemptRecord
 }
 
 func (e ErrorMaxRetryExceeded) Error() string {
 	return "max retry exceeded"
 }
 
+// Unwrap returns errors of all attempts, fallback to LastError if there is no attempt history
+func (e ErrorMaxRetryExceeded) Unwrap() []error {
+	if len(e.Attempts) == 0 {
+		if e.LastError == nil {
+			return nil
+		}
+		return []error{e.LastError}
+	}
+	return Result{Attempts: e.Attempts}.Errors()
+}
+
+type ErrorMaxElapsedTimeExceeded struct {
+	Elapsed        time.Duration
+	MaxElapsedTime time.Duration
+	LastError      error
+}
+
+func (e ErrorMaxElapsedTimeExceeded) Error() string {
+	return fmt.Sprintf("max elapsed time %s exceeded", e.MaxElapsedTime)
+}
+
+func (e ErrorMaxElapsedTimeExceeded) Unwrap() error {
+	return e.LastError
+}
+
+// ErrorWaitBeyondDeadline means next attempt would start after deadline of the context
+type ErrorWaitBeyondDeadline struct {
+	Deadline  time.Time
+	Sleep     time.Duration
+	LastError error
+}
+
+func (e ErrorWaitBeyondDeadline) Error() string {
+	return fmt.Sprintf("next attempt after %s would exceed deadline %s", e.Sleep, e.Deadline.Format(time.RFC3339))
+}
+
+func (e ErrorWaitBeyondDeadline) Unwrap() error {
+	return e.LastError
+}
+
+// ErrorHealthCheckFailed replaces the error of Fn canceled by HealthChecker
+type ErrorHealthCheckFailed struct {
+	Err error
+	// Failures is the count of consecutive probe failures, 0 if not reported by HealthChecker
+	Failures uint
+}
+
+func (e ErrorHealthCheckFailed) Error() string {
+	if e.Failures != 0 {
+		return fmt.Sprintf("health check failed %d times: %v", e.Failures, e.Err)
+	}
+	return fmt.Sprintf("health check failed: %v", e.Err)
+}
+
+func (e ErrorHealthCheckFailed) Unwrap() error {
+	return e.Err
+}
+
+// Is makes ErrorHealthCheckFailed match ErrCauseHealthCheck
+func (e ErrorHealthCheckFailed) Is(target error) bool {
+	return target == ErrCauseHealthCheck
+}
+
+// ErrorHeartbeatTimeout replaces the error of Fn canceled for missing heartbeats
+type ErrorHeartbeatTimeout struct {
+	Timeout  time.Duration
+	LastBeat time.Time
+	// Stack of all goroutines if Conf.HeartbeatDumpStacks, it's too large to be included in Error
+	Stack string
+}
+
+func (e ErrorHeartbeatTimeout) Error() string {
+	return fmt.Sprintf("no heartbeat within %s", e.Timeout)
+}
+
+// Is makes ErrorHeartbeatTimeout match ErrCauseHeartbeatTimeout
+func (e ErrorHeartbeatTimeout) Is(target error) bool {
+	return target == ErrCauseHeartbeatTimeout
+}
+
+// ErrorFnAbandoned means Fn not returned within Conf.AbandonAfter after canceled,
+// the attempt is considered dead and Fn is left running.
+type ErrorFnAbandoned struct {
+	// Cause is the cause of canceling, e.g. *ErrorHealthCheckFailed
+	Cause error
+	After time.Duration
+	// Running is the count of abandoned Fn calls of the Run still running, including this one
+	Running int64
+}
+
+func (e ErrorFnAbandoned) Error() string {
+	return fmt.Sprintf("fn abandoned after not returning for %s since canceled (%d still running): %v", e.After, e.Running, e.Cause)
+}
+
+func (e ErrorFnAbandoned) Unwrap() error {
+	return e.Cause
+}
+
+// ErrorRetryBudgetExhausted means retry is throttled by RetryBudget
+type ErrorRetryBudgetExhausted struct {
+	LastError error
+}
+
+func (e ErrorRetryBudgetExhausted) Error() string {
+	return "retry budget exhausted"
+}
+
+func (e ErrorRetryBudgetExhausted) Unwrap() error {
+	return e.LastError
+}
+
+// ErrorMinUptime means Fn returned nil before running for MinUptime
+type ErrorMinUptime struct {
+	Uptime    time.Duration
+	MinUptime time.Duration
+}
+
+func (e ErrorMinUptime) Error() string {
+	return fmt.Sprintf("exited too quickly after %s, min uptime is %s", e.Uptime, e.MinUptime)
+}
+
+// RetryAfterError tells how long to wait before next attempt, e.g. Retry-After of http responses.
+// Run uses the delay capped at MaxDuration instead of calculated wait time, non-positive value is ignored.
+type RetryAfterError interface {
+	error
+	RetryAfter() time.Duration
+}
+
+// _RetryAfter returns positive delay if any error in the tree implements RetryAfterError
+func _RetryAfter(err error) (time.Duration, bool) {
+	var retryAfter RetryAfterError
+	if errors.As(err, &retryAfter) {
+		if delay := retryAfter.RetryAfter(); delay > 0 {
+			return delay, true
+		}
+	}
+	return 0, false
+}
+
+// ParseRetryAfter parses value of Retry-After header in delay seconds or http date format.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(min(seconds, int64(time.Duration(1<<63-1)/time.Second))) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// ErrorCircuitOpen is returned by CircuitBreaker without calling Fn
+type ErrorCircuitOpen struct {
+	State CircuitState
+	// RetryAfterDelay is the time left of cool-down, zero if a half-open trial is running
+	RetryAfterDelay time.Duration
+}
+
+func (e ErrorCircuitOpen) Error() string {
+	if e.RetryAfterDelay > 0 {
+		return fmt.Sprintf("circuit %s, retry after %s", e.State, e.RetryAfterDelay)
+	}
+	return fmt.Sprintf("circuit %s", e.State)
+}
+
+func (e ErrorCircuitOpen) RetryAfter() time.Duration {
+	return e.RetryAfterDelay
+}
+
 type ErrorUnexpectedHttpStatus struct {
 	HttpStatus int
+	// RetryAfterDelay is parsed from Retry-After header, zero if not provided
+	RetryAfterDelay time.Duration
+}
+
+// NewErrorUnexpectedHttpStatus creates ErrorUnexpectedHttpStatus with Retry-After header parsed
+func NewErrorUnexpectedHttpStatus(resp *http.Response) *ErrorUnexpectedHttpStatus {
+	delay, _ := ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	return &ErrorUnexpectedHttpStatus{
+		HttpStatus:      resp.StatusCode,
+		RetryAfterDelay: delay,
+	}
 }
 
 func (e ErrorUnexpectedHttpStatus) Error() string {
+	if e.RetryAfterDelay > 0 {
+		return fmt.Sprintf("unexpected http status: %v, retry after %s", e.HttpStatus, e.RetryAfterDelay)
+	}
 	return fmt.Sprintf("unexpected http status: %v", e.HttpStatus)
 }
 
+func (e ErrorUnexpectedHttpStatus) RetryAfter() time.Duration {
+	return e.RetryAfterDelay
+}
+
 type ErrorKeywordNotFound struct {
 	Keyword string
 }
@@ -0,0 +1,45 @@
+package backoff
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, c := range []struct {
+		value string
+		delay time.Duration
+		ok    bool
+	}{
+		{"120", time.Minute * 2, true},
+		{" 0 ", 0, true},
+		{"-1", 0, false},
+		{"", 0, false},
+		{"soon", 0, false},
+		{now.Add(time.Minute).Format(http.TimeFormat), time.Minute, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+	} {
+		delay, ok := ParseRetryAfter(c.value, now)
+		assert.Equal(t, c.ok, ok, "value %q", c.value)
+		assert.Equal(t, c.delay, delay, "value %q", c.value)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	delay, ok := _RetryAfter(fmt.Errorf("wrapped: %w", &ErrorUnexpectedHttpStatus{
+		HttpStatus:      http.StatusTooManyRequests,
+		RetryAfterDelay: time.Second * 3,
+	}))
+	assert.True(t, ok)
+	assert.Equal(t, time.Second*3, delay)
+
+	_, ok = _RetryAfter(&ErrorUnexpectedHttpStatus{HttpStatus: http.StatusServiceUnavailable})
+	assert.False(t, ok, "zero delay should be ignored")
+	_, ok = _RetryAfter(assert.AnError)
+	assert.False(t, ok)
+}
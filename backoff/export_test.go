@@ -0,0 +1,4 @@
+package backoff
+
+// NewDiscardLogger exposes _NewDiscardLogger to tests in package backoff_test
+var NewDiscardLogger = _NewDiscardLogger
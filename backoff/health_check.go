@@ -3,8 +3,9 @@ package backoff
 import (
 	"bufio"
 	"context"
-	log "github.com/sirupsen/logrus"
+	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"net/http"
 	"strings"
@@ -14,64 +15,123 @@ import (
 type ProbeHealthCheckFn func(ctx context.Context) error
 
 type ProbeHealthCheckerConfig struct {
-	Logger           log.FieldLogger
+	// Name is the Probe of HealthResult, e.g. "http"
+	Name             string
+	Logger           Logger
 	CheckInterval    time.Duration
 	InitialDelay     time.Duration
 	SuccessThreshold int
 	FailureThreshold int
+	// Clock default RealClock
+	Clock Clock
 }
 
 func NewProbeHealthChecker(fn ProbeHealthCheckFn, conf ProbeHealthCheckerConfig) HealthChecker {
+	return NewProbeHealthResultChecker(fn, conf).Errors()
+}
+
+// NewProbeHealthResultChecker reports HealthPass once SuccessThreshold reached,
+// HealthWarn on failures below FailureThreshold and HealthFail once reached.
+func NewProbeHealthResultChecker(fn ProbeHealthCheckFn, conf ProbeHealthCheckerConfig) HealthResultChecker {
 	if conf.Logger == nil {
-		conf.Logger = log.StandardLogger()
+		conf.Logger = slog.Default()
 	}
-	return func(ctx context.Context) <-chan error {
-		errChan := make(chan error, 1)
+	if conf.Clock == nil {
+		conf.Clock = RealClock()
+	}
+	return func(ctx context.Context) <-chan HealthResult {
+		results := make(chan HealthResult, 1)
+		send := func(result HealthResult) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case results <- result:
+				return true
+			}
+		}
 		var success, failure int
 		go func() {
 			if conf.InitialDelay != 0 {
+				timer, stop := _NewTimer(conf.Clock, conf.InitialDelay)
 				select {
 				case <-ctx.Done():
+					stop()
 					return
-				case <-time.After(conf.InitialDelay):
+				case <-timer:
 				}
 			}
 
 			for {
+				start := conf.Clock.Now()
 				err := fn(ctx)
+				now := conf.Clock.Now()
+				result := HealthResult{
+					Probe:   conf.Name,
+					Latency: now.Sub(start),
+					Time:    now,
+				}
 				if err != nil {
 					failure++
-					conf.Logger.WithFields(log.Fields{
-						"failure":   failure,
-						"threshold": conf.FailureThreshold,
-					}).Warnln("health check failed:", err)
+					conf.Logger.Log(ctx, slog.LevelWarn, fmt.Sprint("health check failed: ", err),
+						"failure", failure,
+						"threshold", conf.FailureThreshold,
+						"latency", result.Latency,
+					)
 					if failure >= conf.FailureThreshold {
-						errChan <- err
+						result.Status, result.Err = HealthFail, &ErrorHealthCheckFailed{Err: err, Failures: uint(failure)}
+						result.Message = result.Err.Error()
+						send(result)
+						return
+					}
+					result.Status, result.Err = HealthWarn, err
+					result.Message = fmt.Sprintf("failed %d/%d times: %v", failure, conf.FailureThreshold, err)
+					if !send(result) {
 						return
 					}
 					success = 0
 				} else {
 					success++
-					conf.Logger.WithFields(log.Fields{
-						"success":   success,
-						"threshold": conf.SuccessThreshold,
-					}).Debugln("health check passed")
+					conf.Logger.Log(ctx, slog.LevelDebug, "health check passed",
+						"success", success,
+						"threshold", conf.SuccessThreshold,
+						"latency", result.Latency,
+					)
 					if success >= conf.SuccessThreshold {
-						errChan <- nil
+						result.Status = HealthPass
+						result.Message = fmt.Sprintf("passed %d times", success)
+						if !send(result) {
+							return
+						}
 					}
 					failure = 0
 				}
 
+				timer, stop := _NewTimer(conf.Clock, conf.CheckInterval)
 				select {
 				case <-ctx.Done():
-					errChan <- ctx.Err()
+					stop()
 					return
-				case <-time.After(conf.CheckInterval):
+				case <-timer:
 					// continue
 				}
 			}
 		}()
-		return errChan
+		return results
+	}
+}
+
+// NewHealthCheckerProbe calls checker until its first result, e.g. as trial of CircuitBreaker.
+// nil result means healthy.
+func NewHealthCheckerProbe(checker HealthChecker) ProbeHealthCheckFn {
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-checker(ctx):
+			return err
+		}
 	}
 }
 
@@ -133,10 +193,10 @@ func NewHttpProbeHealthCheckFn(conf HttpProbeHealthCheckConfig) ProbeHealthCheck
 
 		if conf.HttpStatusCode != 0 {
 			if resp.StatusCode != conf.HttpStatusCode {
-				return &ErrorUnexpectedHttpStatus{HttpStatus: resp.StatusCode}
+				return NewErrorUnexpectedHttpStatus(resp)
 			}
 		} else if resp.StatusCode < 200 || resp.StatusCode > 299 {
-			return &ErrorUnexpectedHttpStatus{HttpStatus: resp.StatusCode}
+			return NewErrorUnexpectedHttpStatus(resp)
 		}
 
 		if conf.Keyword != "" {
@@ -2,10 +2,8 @@ package backoff
 
 import (
 	"context"
-	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -50,8 +48,7 @@ func TestProbeHealthChecker_Success(t *testing.T) {
 func TestProbeHealthChecker_Failure(t *testing.T) {
 	t.Parallel()
 
-	logger := log.New()
-	logger.SetOutput(io.Discard)
+	logger := _NewDiscardLogger()
 
 	var count atomic.Uint32
 	errChan := NewProbeHealthChecker(func(ctx context.Context) error {
@@ -67,6 +64,9 @@ func TestProbeHealthChecker_Failure(t *testing.T) {
 	case err := <-errChan:
 		require.ErrorIs(t, err, assert.AnError)
 		assert.Equal(t, uint32(5), count.Load(), "failure threshold not work properly")
+		var healthErr *ErrorHealthCheckFailed
+		require.ErrorAs(t, err, &healthErr)
+		assert.EqualValues(t, 5, healthErr.Failures)
 	case <-time.After(time.Second):
 		t.Fatal("timeout")
 	}
@@ -145,6 +145,23 @@ func TestHttpProbeHealthCheckFn_Response_Check(t *testing.T) {
 	})(context.Background()), &errorUnexpectedHttpStatus, "expected http code not match")
 }
 
+func TestHttpProbeHealthCheckFn_RetryAfter(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Retry-After", "5")
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var errorUnexpectedHttpStatus *ErrorUnexpectedHttpStatus
+	require.ErrorAs(t, NewHttpProbeHealthCheckFn(HttpProbeHealthCheckConfig{
+		URL: server.URL,
+	})(context.Background()), &errorUnexpectedHttpStatus)
+	assert.Equal(t, http.StatusServiceUnavailable, errorUnexpectedHttpStatus.HttpStatus)
+	assert.Equal(t, time.Second*5, errorUnexpectedHttpStatus.RetryAfter())
+}
+
 func TestHttpProbeHealthCheckFn_Timeout(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,110 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type HealthStatus string
+
+const (
+	HealthPass HealthStatus = "pass"
+	// HealthWarn reports a degraded probe, it neither resets wait time nor cancels Fn
+	HealthWarn HealthStatus = "warn"
+	HealthFail HealthStatus = "fail"
+)
+
+// HealthResult is the structured result of a health check
+type HealthResult struct {
+	Status HealthStatus
+	// Probe is the name of the health check, e.g. "http"
+	Probe   string
+	Message string
+	// Latency of the probe, 0 if unknown
+	Latency time.Duration
+	// Time is when the result produced
+	Time time.Time
+	// Err is the reason of HealthWarn or HealthFail
+	Err error
+}
+
+// NewHealthResult converts result of HealthChecker, nil err means HealthPass.
+func NewHealthResult(err error, now time.Time) HealthResult {
+	if err == nil {
+		return HealthResult{Status: HealthPass, Time: now}
+	}
+	return HealthResult{Status: HealthFail, Message: err.Error(), Time: now, Err: err}
+}
+
+// AsError converts result to the form of HealthChecker, nil if not HealthFail.
+func (r HealthResult) AsError() error {
+	if r.Status != HealthFail {
+		return nil
+	}
+	if r.Err != nil {
+		return r.Err
+	}
+	return errors.New(r.Message)
+}
+
+// HealthResultChecker works like HealthChecker with structured results
+type HealthResultChecker func(ctx context.Context) <-chan HealthResult
+
+// Results adapts HealthChecker to HealthResultChecker, clock stamps Time of results, nil means RealClock.
+func (c HealthChecker) Results(clock Clock) HealthResultChecker {
+	if clock == nil {
+		clock = RealClock()
+	}
+	return func(ctx context.Context) <-chan HealthResult {
+		errChan := c(ctx)
+		results := make(chan HealthResult, 1)
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case err, ok := <-errChan:
+					if !ok {
+						return
+					}
+					select {
+					case <-ctx.Done():
+						return
+					case results <- NewHealthResult(err, clock.Now()):
+					}
+				}
+			}
+		}()
+		return results
+	}
+}
+
+// Errors adapts HealthResultChecker to HealthChecker, HealthWarn results are dropped.
+func (c HealthResultChecker) Errors() HealthChecker {
+	return func(ctx context.Context) <-chan error {
+		results := c(ctx)
+		errChan := make(chan error, 1)
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case result, ok := <-results:
+					if !ok {
+						return
+					}
+					if result.Status == HealthWarn {
+						continue
+					}
+					select {
+					case <-ctx.Done():
+						return
+					case errChan <- result.AsError():
+					}
+				}
+			}
+		}()
+		return errChan
+	}
+}
@@ -0,0 +1,124 @@
+package backoff_test
+
+import (
+	"context"
+	"github.com/Mmx233/BackoffCli/backoff"
+	"github.com/Mmx233/BackoffCli/backoff/backofftest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealthChecker_Results(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	now := time.Now()
+	results := backoff.HealthChecker(func(ctx context.Context) <-chan error {
+		errChan := make(chan error, 2)
+		errChan <- nil
+		errChan <- assert.AnError
+		return errChan
+	}).Results(backofftest.NewFakeClock(now))(ctx)
+
+	result := <-results
+	assert.Equal(t, backoff.HealthPass, result.Status)
+	assert.Equal(t, now, result.Time)
+	assert.NoError(t, result.AsError())
+
+	result = <-results
+	assert.Equal(t, backoff.HealthFail, result.Status)
+	assert.Equal(t, assert.AnError.Error(), result.Message)
+	assert.ErrorIs(t, result.AsError(), assert.AnError)
+}
+
+func TestHealthResultChecker_Errors(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errChan := backoff.HealthResultChecker(func(ctx context.Context) <-chan backoff.HealthResult {
+		results := make(chan backoff.HealthResult, 3)
+		results <- backoff.HealthResult{Status: backoff.HealthWarn, Message: "slow"}
+		results <- backoff.HealthResult{Status: backoff.HealthPass}
+		results <- backoff.HealthResult{Status: backoff.HealthFail, Message: "down"}
+		return results
+	}).Errors()(ctx)
+
+	assert.NoError(t, <-errChan, "warn should be dropped")
+	assert.EqualError(t, <-errChan, "down")
+}
+
+func TestProbeHealthResultChecker(t *testing.T) {
+	t.Parallel()
+
+	var count atomic.Uint32
+	results := backoff.NewProbeHealthResultChecker(func(ctx context.Context) error {
+		if count.Add(1) == 1 {
+			return assert.AnError
+		}
+		return nil
+	}, backoff.ProbeHealthCheckerConfig{
+		Name:             "stub",
+		Logger:           backoff.NewDiscardLogger(),
+		CheckInterval:    time.Millisecond,
+		SuccessThreshold: 1,
+		FailureThreshold: 2,
+	})(context.Background())
+
+	select {
+	case result := <-results:
+		assert.Equal(t, backoff.HealthWarn, result.Status)
+		assert.Equal(t, "stub", result.Probe)
+		assert.ErrorIs(t, result.Err, assert.AnError)
+		assert.Contains(t, result.Message, "failed 1/2 times")
+		assert.False(t, result.Time.IsZero())
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+	select {
+	case result := <-results:
+		assert.Equal(t, backoff.HealthPass, result.Status)
+		assert.Equal(t, "stub", result.Probe)
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+}
+
+func TestBackoff_HealthResultChecker(t *testing.T) {
+	t.Parallel()
+
+	var received []backoff.HealthStatus
+	runner := backoff.New(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, backoff.Conf{
+		Logger: backoff.NewDiscardLogger(),
+		HealthResultChecker: func(ctx context.Context) <-chan backoff.HealthResult {
+			results := make(chan backoff.HealthResult, 2)
+			results <- backoff.HealthResult{Status: backoff.HealthPass, Probe: "stub"}
+			results <- backoff.HealthResult{Status: backoff.HealthWarn, Probe: "stub", Latency: time.Second, Message: "slow"}
+			return results
+		},
+		Observer: backoff.ObserverFuncs{
+			OnHealthCheckResult: func(ctx context.Context, result backoff.HealthResult) {
+				received = append(received, result.Status)
+			},
+		},
+	}).Start(context.Background())
+
+	require.Eventually(t, func() bool {
+		return runner.Snapshot().Health == backoff.HealthDegraded
+	}, time.Second, time.Millisecond, "warn should not cancel Fn")
+	snapshot := runner.Snapshot()
+	assert.Equal(t, backoff.RunnerRunning, snapshot.State)
+	assert.Equal(t, "slow", snapshot.HealthCheck.Message)
+	assert.Equal(t, time.Second, snapshot.HealthCheck.Latency)
+
+	runner.Stop()
+	_, _ = runner.Wait()
+	assert.Equal(t, []backoff.HealthStatus{backoff.HealthPass, backoff.HealthWarn}, received)
+}
@@ -0,0 +1,82 @@
+package backoff
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+type _Heartbeat struct {
+	clock Clock
+	// last is unix nano of the last heartbeat
+	last atomic.Int64
+}
+
+type CtxHeartbeat struct {
+	CtxStructKey[CtxHeartbeat, *_Heartbeat]
+}
+
+// Heartbeat tells Run that Fn is still making progress, see Conf.HeartbeatTimeout.
+// It does nothing if ctx is not passed from Run.
+func Heartbeat(ctx context.Context) {
+	if heartbeat, ok := (CtxHeartbeat{}).Get(ctx); ok {
+		heartbeat.last.Store(heartbeat.clock.Now().UnixNano())
+	}
+}
+
+// _WatchHeartbeat sends *ErrorHeartbeatTimeout to failed before canceling Fn
+func (b Backoff) _WatchHeartbeat(ctx context.Context, failed chan<- error) context.Context {
+	heartbeat := &_Heartbeat{clock: b.Clock()}
+	heartbeat.last.Store(heartbeat.clock.Now().UnixNano())
+	cancelFn := CtxCancelFn{}.Must(ctx)
+	timeout := b.Config.HeartbeatTimeout
+
+	go func() {
+		for {
+			// read now first, a heartbeat in between then never looks stale
+			now := heartbeat.clock.Now()
+			wait := timeout - now.Sub(time.Unix(0, heartbeat.last.Load()))
+			if wait <= 0 {
+				break
+			}
+			timer, stop := _NewTimer(heartbeat.clock, wait)
+			select {
+			case <-ctx.Done():
+				stop()
+				return
+			case <-timer:
+			}
+		}
+
+		if ctx.Err() != nil {
+			// Fn returned or canceled by others
+			return
+		}
+		err := &ErrorHeartbeatTimeout{
+			Timeout:  timeout,
+			LastBeat: time.Unix(0, heartbeat.last.Load()),
+		}
+		if b.Config.HeartbeatDumpStacks {
+			err.Stack = _AllStacks()
+		}
+		select {
+		case failed <- err:
+		default:
+		}
+		cancelFn(err)
+	}()
+	return CtxHeartbeat{}.Set(ctx, heartbeat)
+}
+
+// _AllStacks returns stacks of all goroutines
+func _AllStacks() string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= 64<<20 {
+			return string(buf[:n])
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}
@@ -0,0 +1,65 @@
+package backoff_test
+
+import (
+	"context"
+	"github.com/Mmx233/BackoffCli/backoff"
+	"github.com/Mmx233/BackoffCli/backoff/backofftest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestBackoff_HeartbeatTimeout(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := backofftest.NewFakeClock(time.Unix(0, 0))
+	go func() {
+		_, _ = clock.WaitAndAdvance(ctx)
+	}()
+
+	causes := make(chan error, 1)
+	err := backoff.New(func(ctx context.Context) error {
+		// hang without heartbeat
+		<-ctx.Done()
+		causes <- backoff.CancelCause(ctx)
+		return ctx.Err()
+	}, backoff.Conf{
+		Logger:              backoff.NewDiscardLogger(),
+		Restart:             backoff.RestartNever,
+		HeartbeatTimeout:    time.Second,
+		HeartbeatDumpStacks: true,
+		Clock:               clock,
+	}).Run(ctx)
+
+	var heartbeatErr *backoff.ErrorHeartbeatTimeout
+	require.ErrorAs(t, err, &heartbeatErr, "should count as failure")
+	assert.Equal(t, time.Second, heartbeatErr.Timeout)
+	assert.Contains(t, heartbeatErr.Stack, "TestBackoff_HeartbeatTimeout", "should dump all goroutines")
+	assert.Equal(t, "no heartbeat within 1s", heartbeatErr.Error(), "stacks should not be in message")
+	assert.ErrorIs(t, <-causes, backoff.ErrCauseHeartbeatTimeout)
+}
+
+func TestBackoff_Heartbeat(t *testing.T) {
+	t.Parallel()
+
+	clock := backofftest.NewFakeClock(time.Unix(0, 0))
+	err := backoff.New(func(ctx context.Context) error {
+		for range 10 {
+			backoff.Heartbeat(ctx)
+			clock.Advance(time.Second * 10)
+		}
+		return ctx.Err()
+	}, backoff.Conf{
+		Logger:           backoff.NewDiscardLogger(),
+		Restart:          backoff.RestartNever,
+		HeartbeatTimeout: time.Second * 30,
+		Clock:            clock,
+	}).Run(context.Background())
+	assert.NoError(t, err, "should keep running with heartbeats")
+
+	// no effect without Run
+	backoff.Heartbeat(context.Background())
+}
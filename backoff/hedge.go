@@ -0,0 +1,67 @@
+package backoff
+
+import (
+	"context"
+	"time"
+)
+
+// _CallHedged calls Fn like _CallFn, and starts another call every HedgeDelay
+// until one of them returned or HedgeMaxAttempts calls are running.
+// The first success wins and cancels others, it fails once all calls started failed.
+func (b Backoff) _CallHedged(ctx context.Context) <-chan error {
+	maxAttempts := b.Config.HedgeMaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = 2
+	}
+	clock := b.Clock()
+	attempt, _ := AttemptFromContext(ctx)
+
+	// set capacity to 1 to avoid goroutine leak
+	result := make(chan error, 1)
+	ctx, cancel := context.WithCancelCause(ctx)
+	errChans := make(chan error, maxAttempts)
+	start := func(hedge uint) {
+		attempt.Hedge = hedge
+		errChan := b._CallFn(CtxAttemptInfo{}.Set(ctx, attempt))
+		go func() {
+			errChans <- <-errChan
+		}()
+	}
+
+	go func() {
+		defer cancel(ErrCauseHedgeLost)
+
+		start(0)
+		var started, finished uint = 1, 0
+		// hedgeTimer keeps running when a call failed, it is only reset after fired
+		var hedgeTimer <-chan time.Time
+		stop := func() {}
+		if started < maxAttempts {
+			hedgeTimer, stop = _NewTimer(clock, b.Config.HedgeDelay)
+		}
+		defer func() {
+			stop()
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				result <- ctx.Err()
+				return
+			case <-hedgeTimer:
+				start(started)
+				started++
+				hedgeTimer, stop = nil, func() {}
+				if started < maxAttempts {
+					hedgeTimer, stop = _NewTimer(clock, b.Config.HedgeDelay)
+				}
+			case err := <-errChans:
+				finished++
+				if err == nil || finished == started {
+					result <- err
+					return
+				}
+			}
+		}
+	}()
+	return result
+}
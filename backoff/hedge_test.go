@@ -0,0 +1,120 @@
+package backoff_test
+
+import (
+	"context"
+	"github.com/Mmx233/BackoffCli/backoff"
+	"github.com/Mmx233/BackoffCli/backoff/backofftest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBackoff_Hedge(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	clock := backofftest.NewFakeClock(time.Unix(0, 0))
+	slowStarted := make(chan struct{})
+	go func() {
+		<-slowStarted
+		_, _ = clock.WaitAndAdvance(ctx)
+	}()
+
+	var lock sync.Mutex
+	var hedges []uint
+	canceled := make(chan struct{})
+	value, err := backoff.Do(ctx, backoff.Conf{
+		Logger:     backoff.NewDiscardLogger(),
+		HedgeDelay: time.Second,
+		Clock:      clock,
+	}, func(ctx context.Context) (uint, error) {
+		attempt, _ := backoff.AttemptFromContext(ctx)
+		lock.Lock()
+		hedges = append(hedges, attempt.Hedge)
+		lock.Unlock()
+		if attempt.Hedge == 0 {
+			close(slowStarted)
+			// slow call is canceled once hedged call succeeded
+			<-ctx.Done()
+			close(canceled)
+			return 0, ctx.Err()
+		}
+		return attempt.Hedge, nil
+	})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, value)
+	select {
+	case <-canceled:
+	case <-ctx.Done():
+		t.Fatal("slow call not canceled")
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, []uint{0, 1}, hedges)
+}
+
+func TestBackoff_HedgeMaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	clock := backofftest.NewFakeClock(time.Unix(0, 0))
+	go func() {
+		for range 2 {
+			_, _ = clock.WaitAndAdvance(ctx)
+		}
+	}()
+
+	var lock sync.Mutex
+	var calls []backoff.AttemptInfo
+	allStarted := make(chan struct{})
+	result, err := backoff.New(func(ctx context.Context) error {
+		attempt, _ := backoff.AttemptFromContext(ctx)
+		lock.Lock()
+		calls = append(calls, attempt)
+		if len(calls) == 3 {
+			close(allStarted)
+		}
+		lock.Unlock()
+		select {
+		case <-allStarted:
+		case <-ctx.Done():
+		}
+		return assert.AnError
+	}, backoff.Conf{
+		Logger:           backoff.NewDiscardLogger(),
+		Restart:          backoff.RestartNever,
+		HedgeDelay:       time.Second,
+		HedgeMaxAttempts: 3,
+		Clock:            clock,
+	}).RunWithResult(ctx)
+	assert.ErrorIs(t, err, assert.AnError, "should fail once all calls failed")
+	assert.Len(t, result.Attempts, 1)
+	lock.Lock()
+	defer lock.Unlock()
+	hedges := make([]uint, 0, len(calls))
+	for _, call := range calls {
+		assert.EqualValues(t, 1, call.Attempt)
+		hedges = append(hedges, call.Hedge)
+	}
+	// hedged calls are started without waiting for the previous one
+	assert.ElementsMatch(t, []uint{0, 1, 2}, hedges)
+	assert.Zero(t, clock.Waiters(), "should not start more calls")
+}
+
+func TestBackoff_HedgePanic(t *testing.T) {
+	t.Parallel()
+
+	_, err := backoff.Do(context.Background(), backoff.Conf{
+		Logger:     backoff.NewDiscardLogger(),
+		Restart:    backoff.RestartNever,
+		HedgeDelay: time.Millisecond,
+	}, func(ctx context.Context) (int, error) {
+		panic("test panic")
+	})
+	var errorPanic *backoff.ErrorPanic
+	assert.ErrorAs(t, err, &errorPanic)
+}
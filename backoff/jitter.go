@@ -0,0 +1,64 @@
+package backoff
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Jitter randomizes wait time to avoid retrying on exactly the same schedule.
+// See https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+type Jitter string
+
+const (
+	JitterNone Jitter = "none"
+	// JitterFull sleeps random between 0 and $Wait
+	JitterFull Jitter = "full"
+	// JitterEqual sleeps $Wait / 2 plus random between 0 and $Wait / 2
+	JitterEqual Jitter = "equal"
+	// JitterDecorrelated sleeps random between InitialDuration and $LastSleep * 3,
+	// capped by MaxDuration. $Wait calculated by Strategy is ignored.
+	JitterDecorrelated Jitter = "decorrelated"
+)
+
+// Rand is the random source of jitter, *rand.Rand from math/rand/v2 meets it.
+type Rand interface {
+	Int64N(n int64) int64
+}
+
+type _GlobalRand struct{}
+
+func (_GlobalRand) Int64N(n int64) int64 {
+	return rand.Int64N(n)
+}
+
+func (b Backoff) _RandBetween(lower, upper time.Duration) time.Duration {
+	if upper <= lower {
+		return lower
+	}
+	random := b.Config.Rand
+	if random == nil {
+		random = _GlobalRand{}
+	}
+	return lower + time.Duration(random.Int64N(int64(upper-lower)))
+}
+
+// JitterWait returns the actual sleep time of wait. lastSleep is the value
+// JitterWait returned last time, 0 for the first time or after wait reset.
+func (b Backoff) JitterWait(wait, lastSleep time.Duration) time.Duration {
+	switch b.Config.Jitter {
+	case JitterFull:
+		return b._RandBetween(0, wait)
+	case JitterEqual:
+		return wait/2 + b._RandBetween(0, wait-wait/2)
+	case JitterDecorrelated:
+		lastSleep = max(lastSleep, b.Config.InitialDuration)
+		upper := lastSleep * 3
+		if upper/3 != lastSleep {
+			// overflow
+			upper = b.Config.MaxDuration
+		}
+		return min(b._RandBetween(b.Config.InitialDuration, upper), b.Config.MaxDuration)
+	default:
+		return wait
+	}
+}
@@ -0,0 +1,82 @@
+package backoff
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+// _MaxRand always returns the largest number it can
+type _MaxRand struct{}
+
+func (_MaxRand) Int64N(n int64) int64 {
+	return n - 1
+}
+
+func _NewJitterInstance(jitter Jitter, random Rand) Backoff {
+	return New(func(ctx context.Context) error {
+		return nil
+	}, Conf{
+		InitialDuration: time.Second,
+		MaxDuration:     time.Minute,
+		Jitter:          jitter,
+		Rand:            random,
+	})
+}
+
+func TestBackoff_JitterWait_None(t *testing.T) {
+	instance := _NewJitterInstance(JitterNone, nil)
+	assert.Equal(t, time.Second*4, instance.JitterWait(time.Second*4, time.Second*2))
+
+	instance = _NewJitterInstance("", nil)
+	assert.Equal(t, time.Second*4, instance.JitterWait(time.Second*4, time.Second*2))
+}
+
+func TestBackoff_JitterWait_Range(t *testing.T) {
+	t.Parallel()
+
+	random := rand.New(rand.NewPCG(1, 2))
+	full := _NewJitterInstance(JitterFull, random)
+	equal := _NewJitterInstance(JitterEqual, random)
+	decorrelated := _NewJitterInstance(JitterDecorrelated, random)
+
+	var lastSleep time.Duration
+	for range 1000 {
+		sleep := full.JitterWait(time.Second*4, 0)
+		assert.True(t, sleep >= 0 && sleep <= time.Second*4, "full jitter out of range: %v", sleep)
+
+		sleep = equal.JitterWait(time.Second*4, 0)
+		assert.True(t, sleep >= time.Second*2 && sleep <= time.Second*4, "equal jitter out of range: %v", sleep)
+
+		sleep = decorrelated.JitterWait(0, lastSleep)
+		assert.True(t, sleep >= time.Second && sleep <= max(lastSleep, time.Second)*3, "decorrelated jitter out of range: %v", sleep)
+		assert.LessOrEqual(t, sleep, time.Minute, "decorrelated jitter not capped")
+		lastSleep = sleep
+	}
+}
+
+func TestBackoff_JitterWait_Upper(t *testing.T) {
+	assert.Equal(t, time.Second*4-1, _NewJitterInstance(JitterFull, _MaxRand{}).JitterWait(time.Second*4, 0))
+	assert.Equal(t, time.Second*4-1, _NewJitterInstance(JitterEqual, _MaxRand{}).JitterWait(time.Second*4, 0))
+
+	decorrelated := _NewJitterInstance(JitterDecorrelated, _MaxRand{})
+	assert.Equal(t, time.Second*3-1, decorrelated.JitterWait(0, 0))
+	assert.Equal(t, time.Second*9-1, decorrelated.JitterWait(0, time.Second*3))
+	assert.Equal(t, time.Minute, decorrelated.JitterWait(0, time.Minute))
+}
+
+func TestBackoff_JitterWait_Deterministic(t *testing.T) {
+	sequence := func() []time.Duration {
+		instance := _NewJitterInstance(JitterDecorrelated, rand.New(rand.NewPCG(1, 2)))
+		var sleeps []time.Duration
+		var sleep time.Duration
+		for range 10 {
+			sleep = instance.JitterWait(0, sleep)
+			sleeps = append(sleeps, sleep)
+		}
+		return sleeps
+	}
+	assert.Equal(t, sequence(), sequence(), "same seed should produce same sleeps")
+}
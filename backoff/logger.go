@@ -2,10 +2,12 @@ package backoff
 
 import (
 	"context"
-	log "github.com/sirupsen/logrus"
+	"log/slog"
 )
 
+// Logger is the minimal logging interface used by backoff, args are key-value
+// pairs same as log/slog. *slog.Logger meets Logger, and package logrusadapter
+// adapts logrus.
 type Logger interface {
-	log.FieldLogger
-	WithContext(ctx context.Context) *log.Entry
+	Log(ctx context.Context, level slog.Level, msg string, args ...any)
 }
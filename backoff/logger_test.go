@@ -2,14 +2,92 @@ package backoff
 
 import (
 	"context"
-	log "github.com/sirupsen/logrus"
+	"fmt"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"sync"
 	"testing"
+	"time"
 )
 
+func _NewDiscardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// _RecordHandler keeps all records for assertion
+type _RecordHandler struct {
+	lock    sync.Mutex
+	Records []slog.Record
+}
+
+func (h *_RecordHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *_RecordHandler) Handle(_ context.Context, record slog.Record) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.Records = append(h.Records, record)
+	return nil
+}
+
+func (h *_RecordHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *_RecordHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+func (h *_RecordHandler) Reset() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.Records = nil
+}
+
+func _RecordAttrs(record slog.Record) map[string]any {
+	attrs := make(map[string]any)
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs[attr.Key] = attr.Value.Any()
+		return true
+	})
+	return attrs
+}
+
 func TestLogger(t *testing.T) {
-	_, ok := interface{}(log.New()).(Logger)
-	require.Equal(t, true, ok, "logrus.Logger not meet Logger")
-	_, ok = interface{}(log.New().WithContext(context.Background())).(Logger)
-	require.Equal(t, true, ok, "logrus.Entry not meet Logger")
+	_, ok := interface{}(slog.Default()).(Logger)
+	require.Equal(t, true, ok, "slog.Logger not meet Logger")
+}
+
+func TestLogObserver(t *testing.T) {
+	var handler _RecordHandler
+	observer := NewLogObserver(slog.New(&handler), 1)
+	ctx := context.Background()
+
+	observer.AttemptFailure(ctx, AttemptRecord{Attempt: 1, Err: assert.AnError})
+	observer.WaitScheduled(ctx, RetryState{Retry: 1, Sleep: time.Second, LastError: assert.AnError})
+	require.Len(t, handler.Records, 1)
+	assert.Equal(t, slog.LevelError, handler.Records[0].Level)
+	assert.Equal(t, "failed with error: "+assert.AnError.Error(), handler.Records[0].Message)
+	assert.Equal(t, map[string]any{"rest": uint64(1), "wait": "1s"}, _RecordAttrs(handler.Records[0]))
+
+	handler.Reset()
+	observer.AttemptFailure(ctx, AttemptRecord{Attempt: 2, Err: assert.AnError})
+	observer.GiveUp(ctx, &ErrorMaxRetryExceeded{LastError: assert.AnError})
+	require.Len(t, handler.Records, 2)
+	assert.Equal(t, "failed with error: "+assert.AnError.Error(), handler.Records[0].Message)
+	assert.Equal(t, "max retry exceeded", handler.Records[1].Message)
+
+	handler.Reset()
+	observer.GiveUp(ctx, fmt.Errorf("wrapped: %w", Permanent(assert.AnError)))
+	require.Len(t, handler.Records, 1)
+	assert.Equal(t, "stop retrying: wrapped: permanent error: "+assert.AnError.Error(), handler.Records[0].Message)
+
+	handler.Reset()
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	observer.GiveUp(canceled, canceled.Err())
+	assert.Empty(t, handler.Records, "should not log when canceled by parent")
 }
@@ -0,0 +1,61 @@
+// Package logrusadapter adapts logrus to backoff.Logger
+package logrusadapter
+
+import (
+	"context"
+	"github.com/Mmx233/BackoffCli/backoff"
+	log "github.com/sirupsen/logrus"
+	"log/slog"
+	"time"
+)
+
+func New(logger log.FieldLogger) backoff.Logger {
+	return Logger{FieldLogger: logger}
+}
+
+type Logger struct {
+	log.FieldLogger
+}
+
+func (l Logger) Log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	entry := l.FieldLogger.WithFields(Fields(args...))
+	if ctx != nil {
+		entry = entry.WithContext(ctx)
+	}
+	switch {
+	case level >= slog.LevelError:
+		entry.Errorln(msg)
+	case level >= slog.LevelWarn:
+		entry.Warnln(msg)
+	case level >= slog.LevelInfo:
+		entry.Infoln(msg)
+	default:
+		entry.Debugln(msg)
+	}
+}
+
+// Fields converts slog style key-value pairs to logrus fields, groups are flattened with dot.
+func Fields(args ...any) log.Fields {
+	record := slog.NewRecord(time.Time{}, slog.LevelInfo, "", 0)
+	record.Add(args...)
+	fields := make(log.Fields, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		addAttr(fields, "", attr)
+		return true
+	})
+	return fields
+}
+
+func addAttr(fields log.Fields, prefix string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, attr := range value.Group() {
+			addAttr(fields, prefix, attr)
+		}
+		return
+	}
+	fields[prefix+attr.Key] = value.Any()
+}
@@ -0,0 +1,41 @@
+package logrusadapter
+
+import (
+	"context"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(log.DebugLevel)
+	adapter := New(logger.WithField("comp", "backoff"))
+
+	for level, expected := range map[slog.Level]log.Level{
+		slog.LevelDebug: log.DebugLevel,
+		slog.LevelInfo:  log.InfoLevel,
+		slog.LevelWarn:  log.WarnLevel,
+		slog.LevelError: log.ErrorLevel,
+	} {
+		hook.Reset()
+		adapter.Log(context.Background(), level, "message", "key", "value")
+		entry := hook.LastEntry()
+		require.NotNil(t, entry)
+		assert.Equal(t, expected, entry.Level)
+		assert.Equal(t, "message", entry.Message)
+		assert.Equal(t, log.Fields{"comp": "backoff", "key": "value"}, entry.Data)
+	}
+}
+
+func TestFields(t *testing.T) {
+	assert.Equal(t, log.Fields{
+		"key":       "value",
+		"number":    int64(1),
+		"group.sub": true,
+		"!BADKEY":   "odd",
+	}, Fields("key", "value", slog.Int("number", 1), slog.Group("group", "sub", true), "odd"))
+}
@@ -0,0 +1,221 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// Observer receives events of retry lifecycle.
+// HealthCheckResult and HealthCheckFailure are called from the health check goroutine,
+// other methods are called from the goroutine of Run.
+type Observer interface {
+	AttemptStart(ctx context.Context, attempt AttemptInfo)
+	// AttemptFailure is called once Fn returned an error
+	AttemptFailure(ctx context.Context, attempt AttemptRecord)
+	// WaitScheduled is called before sleeping for next attempt.
+	// LastError of state is nil when restarting after success.
+	WaitScheduled(ctx context.Context, state RetryState)
+	WaitReset(ctx context.Context, reason ResetReason)
+	// HealthCheckResult is called with every result of health check
+	HealthCheckResult(ctx context.Context, result HealthResult)
+	// HealthCheckFailure is called with *ErrorHealthCheckFailed before canceling Fn
+	HealthCheckFailure(ctx context.Context, err error)
+	// GiveUp is called when Run returns an error, including context errors
+	GiveUp(ctx context.Context, err error)
+	Success(ctx context.Context, attempt AttemptRecord)
+}
+
+// ObserverFuncs implements Observer with optional callbacks
+type ObserverFuncs struct {
+	OnAttemptStart       func(ctx context.Context, attempt AttemptInfo)
+	OnAttemptFailure     func(ctx context.Context, attempt AttemptRecord)
+	OnWaitScheduled      func(ctx context.Context, state RetryState)
+	OnWaitReset          func(ctx context.Context, reason ResetReason)
+	OnHealthCheckResult  func(ctx context.Context, result HealthResult)
+	OnHealthCheckFailure func(ctx context.Context, err error)
+	OnGiveUp             func(ctx context.Context, err error)
+	OnSuccess            func(ctx context.Context, attempt AttemptRecord)
+}
+
+func (o ObserverFuncs) AttemptStart(ctx context.Context, attempt AttemptInfo) {
+	if o.OnAttemptStart != nil {
+		o.OnAttemptStart(ctx, attempt)
+	}
+}
+
+func (o ObserverFuncs) AttemptFailure(ctx context.Context, attempt AttemptRecord) {
+	if o.OnAttemptFailure != nil {
+		o.OnAttemptFailure(ctx, attempt)
+	}
+}
+
+func (o ObserverFuncs) WaitScheduled(ctx context.Context, state RetryState) {
+	if o.OnWaitScheduled != nil {
+		o.OnWaitScheduled(ctx, state)
+	}
+}
+
+func (o ObserverFuncs) WaitReset(ctx context.Context, reason ResetReason) {
+	if o.OnWaitReset != nil {
+		o.OnWaitReset(ctx, reason)
+	}
+}
+
+func (o ObserverFuncs) HealthCheckResult(ctx context.Context, result HealthResult) {
+	if o.OnHealthCheckResult != nil {
+		o.OnHealthCheckResult(ctx, result)
+	}
+}
+
+func (o ObserverFuncs) HealthCheckFailure(ctx context.Context, err error) {
+	if o.OnHealthCheckFailure != nil {
+		o.OnHealthCheckFailure(ctx, err)
+	}
+}
+
+func (o ObserverFuncs) GiveUp(ctx context.Context, err error) {
+	if o.OnGiveUp != nil {
+		o.OnGiveUp(ctx, err)
+	}
+}
+
+func (o ObserverFuncs) Success(ctx context.Context, attempt AttemptRecord) {
+	if o.OnSuccess != nil {
+		o.OnSuccess(ctx, attempt)
+	}
+}
+
+// Observers broadcasts events to all observers in order, nil observers are ignored.
+type Observers []Observer
+
+func (o Observers) AttemptStart(ctx context.Context, attempt AttemptInfo) {
+	for _, observer := range o {
+		if observer != nil {
+			observer.AttemptStart(ctx, attempt)
+		}
+	}
+}
+
+func (o Observers) AttemptFailure(ctx context.Context, attempt AttemptRecord) {
+	for _, observer := range o {
+		if observer != nil {
+			observer.AttemptFailure(ctx, attempt)
+		}
+	}
+}
+
+func (o Observers) WaitScheduled(ctx context.Context, state RetryState) {
+	for _, observer := range o {
+		if observer != nil {
+			observer.WaitScheduled(ctx, state)
+		}
+	}
+}
+
+func (o Observers) WaitReset(ctx context.Context, reason ResetReason) {
+	for _, observer := range o {
+		if observer != nil {
+			observer.WaitReset(ctx, reason)
+		}
+	}
+}
+
+func (o Observers) HealthCheckResult(ctx context.Context, result HealthResult) {
+	for _, observer := range o {
+		if observer != nil {
+			observer.HealthCheckResult(ctx, result)
+		}
+	}
+}
+
+func (o Observers) HealthCheckFailure(ctx context.Context, err error) {
+	for _, observer := range o {
+		if observer != nil {
+			observer.HealthCheckFailure(ctx, err)
+		}
+	}
+}
+
+func (o Observers) GiveUp(ctx context.Context, err error) {
+	for _, observer := range o {
+		if observer != nil {
+			observer.GiveUp(ctx, err)
+		}
+	}
+}
+
+func (o Observers) Success(ctx context.Context, attempt AttemptRecord) {
+	for _, observer := range o {
+		if observer != nil {
+			observer.Success(ctx, attempt)
+		}
+	}
+}
+
+// NewLogObserver logs retry lifecycle with logger, Run uses it with Conf.Logger.
+// The returned observer is stateful and should be used for a single Run.
+func NewLogObserver(logger Logger, maxRetry uint) Observer {
+	return &_LogObserver{
+		Logger:   logger,
+		MaxRetry: maxRetry,
+	}
+}
+
+type _LogObserver struct {
+	ObserverFuncs
+	Logger   Logger
+	MaxRetry uint
+	// LastError is the failure not logged yet
+	LastError error
+}
+
+func (o *_LogObserver) AttemptFailure(_ context.Context, attempt AttemptRecord) {
+	o.LastError = attempt.Err
+}
+
+func (o *_LogObserver) WaitScheduled(ctx context.Context, state RetryState) {
+	o.LastError = nil
+	if state.LastError == nil {
+		o.Logger.Log(ctx, slog.LevelInfo, fmt.Sprintf("exited, restart in %.0fs", state.Sleep.Seconds()))
+		return
+	}
+	var args []any
+	if o.MaxRetry != 0 && state.Retry <= o.MaxRetry+1 {
+		args = append(args, "rest", o.MaxRetry+1-state.Retry)
+	}
+	args = append(args, "wait", fmt.Sprintf("%.0fs", state.Sleep.Seconds()))
+	o.Logger.Log(ctx, slog.LevelError, fmt.Sprintf("failed with error: %v", state.LastError), args...)
+}
+
+func (o *_LogObserver) WaitReset(ctx context.Context, reason ResetReason) {
+	switch reason {
+	case ResetReasonHealthCheck:
+		o.Logger.Log(ctx, slog.LevelDebug, "wait time reset by health check")
+	case ResetReasonUptime:
+		o.Logger.Log(ctx, slog.LevelDebug, "wait time reset by uptime")
+	}
+}
+
+func (o *_LogObserver) HealthCheckFailure(ctx context.Context, err error) {
+	o.Logger.Log(ctx, slog.LevelWarn, err.Error())
+}
+
+func (o *_LogObserver) GiveUp(ctx context.Context, err error) {
+	if err == ctx.Err() {
+		// canceled by parent
+		return
+	}
+	var permanent *ErrorPermanent
+	switch {
+	case errors.As(err, &permanent):
+		o.Logger.Log(ctx, slog.LevelError, fmt.Sprintf("stop retrying: %v", err))
+	case o.LastError != nil && !errors.Is(o.LastError, err):
+		o.Logger.Log(ctx, slog.LevelError, fmt.Sprintf("failed with error: %v", o.LastError))
+		o.Logger.Log(ctx, slog.LevelError, err.Error())
+	default:
+		o.Logger.Log(ctx, slog.LevelError, fmt.Sprintf("failed with error: %v", err))
+	}
+	o.LastError = nil
+}
@@ -0,0 +1,110 @@
+package backoff_test
+
+import (
+	"context"
+	"github.com/Mmx233/BackoffCli/backoff"
+	"github.com/Mmx233/BackoffCli/backoff/backofftest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestObserver(t *testing.T) {
+	t.Parallel()
+
+	var recorder backofftest.Recorder
+	var count int
+	err := backoff.New(func(ctx context.Context) error {
+		count++
+		if count == 3 {
+			return nil
+		}
+		return assert.AnError
+	}, backoff.Conf{
+		Logger:          backoff.NewDiscardLogger(),
+		InitialDuration: time.Millisecond,
+		MaxDuration:     time.Millisecond * 10,
+		Observer:        &recorder,
+	}).Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"start 1",
+		"failure 1: " + assert.AnError.Error(),
+		"wait 1ms",
+		"start 2",
+		"failure 2: " + assert.AnError.Error(),
+		"wait 2ms",
+		"start 3",
+		"success 3",
+	}, recorder.Events())
+}
+
+func TestObserver_GiveUp(t *testing.T) {
+	t.Parallel()
+
+	var recorder backofftest.Recorder
+	err := backoff.New(func(ctx context.Context) error {
+		return assert.AnError
+	}, backoff.Conf{
+		Logger:          backoff.NewDiscardLogger(),
+		InitialDuration: time.Millisecond,
+		MaxRetry:        1,
+		Observer:        backoff.Observers{nil, &recorder},
+	}).Run(context.Background())
+	require.Error(t, err)
+
+	assert.Equal(t, []string{
+		"start 1",
+		"failure 1: " + assert.AnError.Error(),
+		"wait 1ms",
+		"start 2",
+		"failure 2: " + assert.AnError.Error(),
+		"give up: max retry exceeded",
+	}, recorder.Events())
+}
+
+func TestObserver_HealthCheck(t *testing.T) {
+	t.Parallel()
+
+	var recorder backofftest.Recorder
+	var count int
+	var healthCheckCount atomic.Uint32
+	err := backoff.New(func(ctx context.Context) error {
+		count++
+		if count == 2 {
+			return nil
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}, backoff.Conf{
+		Logger:          backoff.NewDiscardLogger(),
+		InitialDuration: time.Millisecond,
+		Observer:        &recorder,
+		HealthChecker: func(ctx context.Context) <-chan error {
+			errChan := make(chan error, 2)
+			if healthCheckCount.Add(1) == 1 {
+				errChan <- nil
+				errChan <- assert.AnError
+			}
+			return errChan
+		},
+	}).Run(context.Background())
+	require.NoError(t, err)
+
+	healthErr := &backoff.ErrorHealthCheckFailed{Err: assert.AnError}
+	// health check events are sent from another goroutine
+	assert.ElementsMatch(t, []string{
+		"start 1",
+		"health check pass",
+		"reset health_check",
+		"health check fail",
+		"health check failure: " + healthErr.Error(),
+		"failure 1: " + healthErr.Error(),
+		"wait 1ms",
+		"start 2",
+		"success 2",
+	}, recorder.Events())
+}
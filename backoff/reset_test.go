@@ -0,0 +1,111 @@
+package backoff_test
+
+import (
+	"context"
+	"github.com/Mmx233/BackoffCli/backoff"
+	"github.com/Mmx233/BackoffCli/backoff/backofftest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// _AutoAdvance fires waiters of clock one by one until ctx done
+func _AutoAdvance(ctx context.Context, clock *backofftest.FakeClock) {
+	go func() {
+		for {
+			if _, err := clock.WaitAndAdvance(ctx); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+func TestBackoff_ResetUptimeInterval(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		cooldown time.Duration
+		wait     time.Duration
+		reason   backoff.ResetReason
+	}{
+		{"reset by uptime", 0, time.Second * 2, backoff.ResetReasonUptime},
+		{"within cooldown", time.Minute, time.Second * 8, ""},
+		{"after cooldown", time.Second * 40, time.Second * 2, backoff.ResetReasonUptime},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+			defer cancel()
+			clock := backofftest.NewFakeClock(time.Unix(0, 0))
+			_AutoAdvance(ctx, clock)
+
+			var waits []time.Duration
+			result, err := backoff.New(func(ctx context.Context) error {
+				attempt, _ := backoff.AttemptFromContext(ctx)
+				waits = append(waits, attempt.Wait)
+				switch attempt.Attempt {
+				case 4:
+					// healthy for a while
+					clock.Advance(time.Second * 50)
+				case 5:
+					return nil
+				}
+				return assert.AnError
+			}, backoff.Conf{
+				Logger:              backoff.NewDiscardLogger(),
+				InitialDuration:     time.Second,
+				ResetDecay:          2,
+				ResetCooldown:       testCase.cooldown,
+				ResetUptimeInterval: time.Second * 20,
+				Clock:               clock,
+			}).RunWithResult(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, []time.Duration{
+				0, time.Second, time.Second * 2, time.Second * 4, testCase.wait,
+			}, waits, "wait time should be halved once per interval of uptime")
+			assert.Equal(t, testCase.reason, result.Attempts[3].ResetReason)
+		})
+	}
+}
+
+func TestBackoff_ResetCooldown(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	clock := backofftest.NewFakeClock(time.Unix(0, 0))
+	_AutoAdvance(ctx, clock)
+
+	var waits []time.Duration
+	result, err := backoff.New(func(ctx context.Context) error {
+		attempt, _ := backoff.AttemptFromContext(ctx)
+		waits = append(waits, attempt.Wait)
+		switch attempt.Attempt {
+		case 1:
+			return assert.AnError
+		case 3:
+			clock.Advance(time.Second * 10)
+		case 4:
+			cancel()
+			return ctx.Err()
+		}
+		return nil
+	}, backoff.Conf{
+		Logger:          backoff.NewDiscardLogger(),
+		InitialDuration: time.Second,
+		ResetCooldown:   time.Second * 10,
+		Restart:         backoff.RestartAlways,
+		Clock:           clock,
+	}).RunWithResult(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []time.Duration{
+		0, time.Second, time.Second * 2, time.Second,
+	}, waits, "success within cooldown should not reset wait time")
+	require.Len(t, result.Attempts, 4)
+	assert.Empty(t, result.Attempts[1].ResetReason)
+	assert.Equal(t, backoff.ResetReasonSuccess, result.Attempts[2].ResetReason)
+}
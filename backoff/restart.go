@@ -0,0 +1,28 @@
+package backoff
+
+// RestartPolicy decides whether Fn should be started again after it returned.
+type RestartPolicy string
+
+const (
+	// RestartOnFailure restarts Fn when it returns an error, it's the default policy.
+	RestartOnFailure RestartPolicy = "on-failure"
+	// RestartAlways restarts Fn no matter what it returns, even ErrorPermanent.
+	RestartAlways RestartPolicy = "always"
+	// RestartUnlessStopped restarts Fn no matter what it returns,
+	// unless it is stopped by ErrorPermanent.
+	RestartUnlessStopped RestartPolicy = "unless-stopped"
+	// RestartNever runs Fn only once.
+	RestartNever RestartPolicy = "never"
+)
+
+func (p RestartPolicy) OnSuccess() bool {
+	return p == RestartAlways || p == RestartUnlessStopped
+}
+
+func (p RestartPolicy) OnFailure() bool {
+	return p != RestartNever
+}
+
+func (p RestartPolicy) OnPermanent() bool {
+	return p == RestartAlways
+}
@@ -0,0 +1,130 @@
+package backoff
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func _NewRestartInstance(policy RestartPolicy, fn Fn) Backoff {
+	logger := _NewDiscardLogger()
+	return New(fn, Conf{
+		Logger:          logger,
+		InitialDuration: time.Millisecond,
+		MaxDuration:     time.Millisecond,
+		Restart:         policy,
+	})
+}
+
+func TestRestartPolicy_OnSuccess(t *testing.T) {
+	t.Parallel()
+
+	for _, policy := range []RestartPolicy{RestartAlways, RestartUnlessStopped} {
+		ctx, cancel := context.WithCancel(context.Background())
+		var count int
+		err := _NewRestartInstance(policy, func(ctx context.Context) error {
+			count++
+			if count == 3 {
+				cancel()
+			}
+			return nil
+		}).Run(ctx)
+		cancel()
+		assert.ErrorIs(t, err, context.Canceled, "%s: should keep restarting until canceled", policy)
+		assert.Equal(t, 3, count, policy)
+	}
+
+	for _, policy := range []RestartPolicy{RestartOnFailure, RestartNever} {
+		var count int
+		assert.NoError(t, _NewRestartInstance(policy, func(ctx context.Context) error {
+			count++
+			return nil
+		}).Run(context.Background()))
+		assert.Equal(t, 1, count, policy)
+	}
+}
+
+func TestRestartPolicy_MaxRetry(t *testing.T) {
+	t.Parallel()
+
+	var count int
+	instance := _NewRestartInstance(RestartAlways, func(ctx context.Context) error {
+		count++
+		if count%2 == 0 || count > 6 {
+			return assert.AnError
+		}
+		return nil
+	})
+	instance.Config.MaxRetry = 1
+	err := instance.Run(context.Background())
+
+	var maxRetryErr *ErrorMaxRetryExceeded
+	require.ErrorAs(t, err, &maxRetryErr)
+	assert.Equal(t, 7, count, "retry count should be reset after success")
+}
+
+func TestRestartPolicy_Never(t *testing.T) {
+	t.Parallel()
+
+	var count int
+	err := _NewRestartInstance(RestartNever, func(ctx context.Context) error {
+		count++
+		return assert.AnError
+	}).Run(context.Background())
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, 1, count)
+}
+
+func TestRestartPolicy_Permanent(t *testing.T) {
+	t.Parallel()
+
+	var count int
+	err := _NewRestartInstance(RestartUnlessStopped, func(ctx context.Context) error {
+		count++
+		if count == 1 {
+			return nil
+		}
+		return Permanent(assert.AnError)
+	}).Run(context.Background())
+	var errorPermanent *ErrorPermanent
+	assert.ErrorAs(t, err, &errorPermanent)
+	assert.Equal(t, 2, count)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	count = 0
+	err = _NewRestartInstance(RestartAlways, func(ctx context.Context) error {
+		count++
+		if count == 3 {
+			cancel()
+		}
+		return Permanent(assert.AnError)
+	}).Run(ctx)
+	assert.ErrorIs(t, err, context.Canceled, "always should ignore permanent error")
+	assert.Equal(t, 3, count)
+}
+
+func TestBackoff_MinUptime(t *testing.T) {
+	t.Parallel()
+
+	logger := _NewDiscardLogger()
+
+	var count int
+	err := New(func(ctx context.Context) error {
+		count++
+		return nil
+	}, Conf{
+		Logger:          logger,
+		InitialDuration: time.Millisecond,
+		MaxRetry:        2,
+		MinUptime:       time.Minute,
+	}).Run(context.Background())
+
+	var errorMaxRetry *ErrorMaxRetryExceeded
+	require.ErrorAs(t, err, &errorMaxRetry)
+	var errorMinUptime *ErrorMinUptime
+	assert.ErrorAs(t, errorMaxRetry.LastError, &errorMinUptime)
+	assert.Equal(t, 3, count)
+}
@@ -0,0 +1,43 @@
+package backoff
+
+import (
+	"time"
+)
+
+// ResetReason tells why wait time was reset to InitialDuration
+type ResetReason string
+
+const (
+	ResetReasonHealthCheck ResetReason = "health_check"
+	ResetReasonSuccess     ResetReason = "success"
+	// ResetReasonUptime means Fn failed after running longer than Conf.ResetUptimeInterval
+	ResetReasonUptime ResetReason = "uptime"
+)
+
+type AttemptRecord struct {
+	// Attempt starts from 1, same as AttemptInfo.Attempt
+	Attempt  uint
+	StartAt  time.Time
+	Duration time.Duration
+	// Err returned by Fn, nil if succeeded
+	Err error
+	// Wait is the time slept after this attempt, zero if there is no next attempt
+	Wait time.Duration
+	// ResetReason is not empty if wait time was reset during or after this attempt
+	ResetReason ResetReason
+}
+
+type Result struct {
+	Attempts []AttemptRecord
+}
+
+// Errors returns all non-nil errors of attempts
+func (r Result) Errors() []error {
+	errs := make([]error, 0, len(r.Attempts))
+	for _, attempt := range r.Attempts {
+		if attempt.Err != nil {
+			errs = append(errs, attempt.Err)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,108 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestBackoff_RunWithResult(t *testing.T) {
+	t.Parallel()
+
+	logger := _NewDiscardLogger()
+
+	errs := []error{assert.AnError, context.DeadlineExceeded, nil}
+	var count int
+	result, err := New(func(ctx context.Context) error {
+		count++
+		return errs[count-1]
+	}, Conf{
+		Logger:          logger,
+		InitialDuration: time.Millisecond,
+		MaxDuration:     time.Millisecond * 10,
+	}).RunWithResult(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, result.Attempts, 3)
+	for i, attempt := range result.Attempts {
+		assert.Equal(t, uint(i+1), attempt.Attempt)
+		assert.Equal(t, errs[i], attempt.Err)
+		assert.False(t, attempt.StartAt.IsZero())
+		assert.Empty(t, attempt.ResetReason)
+	}
+	assert.Equal(t, time.Millisecond, result.Attempts[0].Wait)
+	assert.Equal(t, time.Millisecond*2, result.Attempts[1].Wait)
+	assert.Zero(t, result.Attempts[2].Wait)
+	assert.Equal(t, errs[:2], result.Errors())
+}
+
+func TestBackoff_MaxHistory(t *testing.T) {
+	t.Parallel()
+
+	var windows []int
+	conf := Conf{
+		Logger:          _NewDiscardLogger(),
+		InitialDuration: time.Millisecond,
+		MaxDuration:     time.Millisecond,
+		MaxRetry:        4,
+		StopPolicy: StopPolicyFunc(func(ctx context.Context, state RetryState) error {
+			windows = append(windows, len(state.Attempts))
+			return nil
+		}),
+	}
+	fn := func(ctx context.Context) error {
+		return assert.AnError
+	}
+
+	err := New(fn, conf).Run(context.Background())
+	var maxRetryErr *ErrorMaxRetryExceeded
+	require.ErrorAs(t, err, &maxRetryErr)
+	assert.Equal(t, []int{1, 2, 3, 4}, windows, "Run should keep all consecutive failures")
+	require.Len(t, maxRetryErr.Attempts, 5)
+	assert.EqualValues(t, 1, maxRetryErr.Attempts[0].Attempt)
+
+	windows = nil
+	_, err = New(fn, conf).RunWithResult(context.Background())
+	require.ErrorAs(t, err, &maxRetryErr)
+	assert.Equal(t, []int{1, 2, 3, 4}, windows, "StopPolicy should see the same as Run")
+
+	windows = nil
+	conf.MaxHistory = 2
+	result, err := New(fn, conf).RunWithResult(context.Background())
+	require.ErrorAs(t, err, &maxRetryErr)
+	assert.Equal(t, []int{1, 2, 2, 2}, windows)
+	require.Len(t, result.Attempts, 2)
+	assert.EqualValues(t, 4, result.Attempts[0].Attempt)
+	assert.EqualValues(t, 5, result.Attempts[1].Attempt)
+	assert.Equal(t, time.Millisecond, result.Attempts[0].Wait)
+}
+
+func TestErrorMaxRetryExceeded_Unwrap(t *testing.T) {
+	t.Parallel()
+
+	logger := _NewDiscardLogger()
+
+	errs := []error{assert.AnError, context.DeadlineExceeded, errors.New("last")}
+	var count int
+	result, err := New(func(ctx context.Context) error {
+		count++
+		return errs[count-1]
+	}, Conf{
+		Logger:          logger,
+		InitialDuration: time.Millisecond,
+		MaxRetry:        2,
+	}).RunWithResult(context.Background())
+
+	var errorMaxRetry *ErrorMaxRetryExceeded
+	require.ErrorAs(t, err, &errorMaxRetry)
+	assert.Equal(t, errs[2], errorMaxRetry.LastError)
+	assert.Equal(t, result.Attempts, errorMaxRetry.Attempts)
+	for _, e := range errs {
+		assert.ErrorIs(t, err, e)
+	}
+
+	assert.ErrorIs(t, &ErrorMaxRetryExceeded{LastError: assert.AnError}, assert.AnError, "should unwrap LastError without history")
+}
@@ -0,0 +1,178 @@
+package backoff
+
+import (
+	"context"
+	"iter"
+	"time"
+)
+
+// Retrier computes waits between attempts for caller-driven retry loops,
+// with the same wait time, jitter and stop logic as Run.
+// It is not safe for concurrent use.
+type Retrier struct {
+	Backoff Backoff
+
+	ctx        context.Context
+	stopPolicy StopPolicy
+	runAt      time.Time
+
+	retry uint
+	wait  time.Duration
+	sleep time.Duration
+	// healthWait and healthSleep track wait time of Conf.HealthCheckWait
+	healthWait  time.Duration
+	healthSleep time.Duration
+	err         error
+}
+
+// NewRetrier creates Retrier with default values same as New.
+// ctx is passed to StopPolicy.
+func NewRetrier(ctx context.Context, conf Conf) *Retrier {
+	return New(nil, conf).NewRetrier(ctx)
+}
+
+func (b Backoff) NewRetrier(ctx context.Context) *Retrier {
+	return &Retrier{
+		Backoff:    b,
+		ctx:        ctx,
+		stopPolicy: b.StopPolicy(),
+		runAt:      b.Clock().Now(),
+		wait:       b.Config.InitialDuration,
+	}
+}
+
+// Next returns the time to sleep before next attempt.
+// If false is returned, the retry loop should give up, see Err for reason.
+func (r *Retrier) Next() (time.Duration, bool) {
+	return r.NextError(nil)
+}
+
+// NextError is same as Next, but gives up immediately if err is permanent,
+// and follows the delay of RetryAfterError.
+func (r *Retrier) NextError(err error) (time.Duration, bool) {
+	if err != nil && r.err == nil {
+		if permanent := r.Backoff._Permanent(err); permanent != nil {
+			r.err = permanent
+		}
+	}
+	state, err := r._Next(err, nil)
+	return state.Sleep, err == nil
+}
+
+func (r *Retrier) _Next(lastErr error, attempts []AttemptRecord) (RetryState, error) {
+	if r.err != nil {
+		return RetryState{}, r.err
+	}
+	r.retry++
+	policy, separate := r.Backoff._WaitPolicy(lastErr)
+	wait, sleep := &r.wait, &r.sleep
+	if separate {
+		wait, sleep = &r.healthWait, &r.healthSleep
+		if *wait == 0 {
+			*wait = policy.InitialDuration
+		}
+	}
+	if delay, ok := _RetryAfter(lastErr); ok {
+		*sleep = min(delay, policy.MaxDuration)
+	} else {
+		*sleep = min(r.Backoff.JitterWait(*wait, *sleep), policy.MaxDuration)
+	}
+	state := RetryState{
+		Retry:     r.retry,
+		Elapsed:   r.Backoff.Clock().Now().Sub(r.runAt),
+		Sleep:     *sleep,
+		LastError: lastErr,
+		Attempts:  attempts,
+	}
+	if err := r.stopPolicy.Stop(r.ctx, state); err != nil {
+		r.err = err
+		return state, err
+	}
+	*wait = policy.Next(*wait)
+	return state, nil
+}
+
+// _Restart returns state of restarting after success, retry count is reset.
+// Wait time decays if reset, otherwise it grows like after failure.
+func (r *Retrier) _Restart(attempts []AttemptRecord, reset bool) RetryState {
+	r.retry = 0
+	if reset {
+		r.Decay(1)
+	}
+	r.sleep = min(r.Backoff.JitterWait(r.wait, r.sleep), r.Backoff.Config.MaxDuration)
+	if !reset {
+		r.wait = r.Backoff.NextWait(r.wait)
+	}
+	return RetryState{
+		Retry:    r.retry,
+		Elapsed:  r.Backoff.Clock().Now().Sub(r.runAt),
+		Sleep:    r.sleep,
+		Attempts: attempts,
+	}
+}
+
+// Reset wait time to InitialDuration, e.g. the operation has recovered.
+// Retry count is not reset, it is only reset when restarting after success.
+func (r *Retrier) Reset() {
+	r.wait, r.sleep = r.Backoff.Config.InitialDuration, 0
+	r.healthWait, r.healthSleep = 0, 0
+}
+
+// Decay divides wait time by Conf.ResetDecay for times, not less than InitialDuration.
+// It works same as Reset if ResetDecay is not greater than 1.
+func (r *Retrier) Decay(times uint) {
+	decay := r.Backoff.Config.ResetDecay
+	if decay <= 1 {
+		r.Reset()
+		return
+	}
+	policy, _ := r.Backoff._WaitPolicy(&ErrorHealthCheckFailed{})
+	for ; times > 0 && (r.wait > r.Backoff.Config.InitialDuration || r.healthWait > policy.InitialDuration); times-- {
+		r.wait = max(_FloatDuration(float64(r.wait)/decay), r.Backoff.Config.InitialDuration)
+		r.sleep = _FloatDuration(float64(r.sleep) / decay)
+		if r.healthWait != 0 {
+			r.healthWait = max(_FloatDuration(float64(r.healthWait)/decay), policy.InitialDuration)
+			r.healthSleep = _FloatDuration(float64(r.healthSleep) / decay)
+		}
+	}
+}
+
+// Err returns the reason of giving up, nil if Retrier is still retrying.
+func (r *Retrier) Err() error {
+	return r.err
+}
+
+// Attempts yields attempt number starting from 1 and the time slept before it.
+// The first attempt is yielded immediately, and the loop ends when retry
+// policy gives up or context is done. Break the loop once succeeded.
+//
+//	for attempt, wait := range backoff.Attempts(ctx, conf) {
+//		if err := do(); err == nil {
+//			break
+//		}
+//	}
+func Attempts(ctx context.Context, conf Conf) iter.Seq2[int, time.Duration] {
+	return func(yield func(int, time.Duration) bool) {
+		retrier := NewRetrier(ctx, conf)
+		clock := retrier.Backoff.Clock()
+		if !yield(1, 0) {
+			return
+		}
+		for attempt := 2; ; attempt++ {
+			sleep, ok := retrier.Next()
+			if !ok {
+				return
+			}
+			timer, stop := _NewTimer(clock, sleep)
+			select {
+			case <-ctx.Done():
+				stop()
+				return
+			case <-timer:
+			}
+			if !yield(attempt, sleep) {
+				return
+			}
+		}
+	}
+}
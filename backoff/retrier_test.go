@@ -0,0 +1,157 @@
+package backoff
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRetrier_Next(t *testing.T) {
+	retrier := NewRetrier(context.Background(), Conf{
+		InitialDuration: time.Second,
+		MaxDuration:     time.Second * 4,
+		MaxRetry:        4,
+	})
+
+	var sleeps []time.Duration
+	for {
+		sleep, ok := retrier.Next()
+		if !ok {
+			break
+		}
+		sleeps = append(sleeps, sleep)
+	}
+	assert.Equal(t, []time.Duration{
+		time.Second, time.Second * 2, time.Second * 4, time.Second * 4,
+	}, sleeps)
+	var errorMaxRetry *ErrorMaxRetryExceeded
+	assert.ErrorAs(t, retrier.Err(), &errorMaxRetry)
+
+	_, ok := retrier.Next()
+	assert.False(t, ok, "should keep giving up")
+}
+
+func TestRetrier_Reset(t *testing.T) {
+	retrier := NewRetrier(context.Background(), Conf{
+		InitialDuration: time.Second,
+	})
+	retrier.Next()
+	retrier.Next()
+	retrier.Reset()
+	sleep, ok := retrier.Next()
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, sleep)
+}
+
+func TestRetrier_NextError(t *testing.T) {
+	retrier := NewRetrier(context.Background(), Conf{})
+	_, ok := retrier.NextError(assert.AnError)
+	assert.True(t, ok)
+
+	_, ok = retrier.NextError(Permanent(assert.AnError))
+	assert.False(t, ok)
+	var permanent *ErrorPermanent
+	require.ErrorAs(t, retrier.Err(), &permanent)
+	assert.ErrorIs(t, permanent, assert.AnError)
+}
+
+func TestAttempts(t *testing.T) {
+	t.Parallel()
+
+	var attempts []int
+	var sleeps []time.Duration
+	for attempt, sleep := range Attempts(context.Background(), Conf{
+		InitialDuration: time.Millisecond,
+		MaxRetry:        2,
+	}) {
+		attempts = append(attempts, attempt)
+		sleeps = append(sleeps, sleep)
+	}
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+	assert.Equal(t, []time.Duration{0, time.Millisecond, time.Millisecond * 2}, sleeps)
+}
+
+func TestAttempts_Break(t *testing.T) {
+	t.Parallel()
+
+	var count int
+	for attempt := range Attempts(context.Background(), Conf{InitialDuration: time.Millisecond}) {
+		count++
+		if attempt == 2 {
+			break
+		}
+	}
+	assert.Equal(t, 2, count)
+}
+
+func TestAttempts_Context(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var count int
+	for range Attempts(ctx, Conf{InitialDuration: time.Hour}) {
+		count++
+		cancel()
+	}
+	assert.Equal(t, 1, count, "should stop waiting once context is done")
+}
+
+func TestRetrier_RetryAfter(t *testing.T) {
+	retrier := NewRetrier(context.Background(), Conf{
+		InitialDuration: time.Second,
+		MaxDuration:     time.Minute,
+	})
+	sleep, _ := retrier.NextError(&ErrorUnexpectedHttpStatus{RetryAfterDelay: time.Second * 30})
+	assert.Equal(t, time.Second*30, sleep)
+	sleep, _ = retrier.NextError(&ErrorUnexpectedHttpStatus{RetryAfterDelay: time.Hour})
+	assert.Equal(t, time.Minute, sleep, "should be capped at MaxDuration")
+	sleep, _ = retrier.NextError(assert.AnError)
+	assert.Equal(t, time.Second*4, sleep, "wait time should still grow")
+}
+
+func TestRetrier_HealthCheckWait(t *testing.T) {
+	retrier := NewRetrier(context.Background(), Conf{
+		InitialDuration: time.Second,
+		HealthCheckWait: &WaitPolicy{
+			InitialDuration: time.Millisecond * 100,
+			Strategy:        ConstantStrategy{},
+		},
+	})
+	healthErr := &ErrorHealthCheckFailed{Err: assert.AnError}
+
+	var sleeps []time.Duration
+	for _, err := range []error{assert.AnError, healthErr, assert.AnError, healthErr, healthErr} {
+		sleep, _ := retrier.NextError(err)
+		sleeps = append(sleeps, sleep)
+	}
+	assert.Equal(t, []time.Duration{
+		time.Second, time.Millisecond * 100, time.Second * 2, time.Millisecond * 100, time.Millisecond * 100,
+	}, sleeps, "health check failures should follow their own wait time")
+}
+
+func TestRetrier_Decay(t *testing.T) {
+	retrier := NewRetrier(context.Background(), Conf{
+		InitialDuration: time.Second,
+		ResetDecay:      2,
+	})
+	for range 4 {
+		retrier.Next()
+	}
+	retrier.Decay(1)
+	sleep, _ := retrier.Next()
+	assert.Equal(t, time.Second*8, sleep, "should halve wait time")
+
+	retrier.Decay(10)
+	sleep, _ = retrier.Next()
+	assert.Equal(t, time.Second, sleep, "should not be less than InitialDuration")
+
+	retrier = NewRetrier(context.Background(), Conf{InitialDuration: time.Second})
+	retrier.Next()
+	retrier.Next()
+	retrier.Decay(1)
+	sleep, _ = retrier.Next()
+	assert.Equal(t, time.Second, sleep, "should reset directly without ResetDecay")
+}
@@ -0,0 +1,214 @@
+package backoff
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type RunnerState string
+
+const (
+	RunnerRunning RunnerState = "running"
+	RunnerWaiting RunnerState = "waiting"
+	RunnerPaused  RunnerState = "paused"
+	RunnerStopped RunnerState = "stopped"
+)
+
+type HealthState string
+
+const (
+	// HealthUnknown means no health check result since the attempt started
+	HealthUnknown   HealthState = "unknown"
+	HealthHealthy   HealthState = "healthy"
+	HealthUnhealthy HealthState = "unhealthy"
+	// HealthDegraded means the last health check result is HealthWarn
+	HealthDegraded HealthState = "degraded"
+)
+
+// Snapshot is the state of Runner at the moment
+type Snapshot struct {
+	State RunnerState
+	// Attempt is the count of attempts started
+	Attempt uint
+	// Wait is the time to sleep scheduled last time
+	Wait time.Duration
+	// WaitUntil is when the next attempt starts, zero if not waiting
+	WaitUntil time.Time
+	// LastError is the error of the last failed attempt, nil after success
+	LastError error
+	Health    HealthState
+	// HealthCheck is the last health check result of the attempt, zero if none
+	HealthCheck HealthResult
+	// Abandoned is the count of abandoned Fn calls still running, see Conf.AbandonAfter
+	Abandoned int64
+}
+
+// Runner controls Backoff running in background, see Start.
+type Runner struct {
+	clock    Clock
+	cancel   context.CancelCauseFunc
+	done     chan struct{}
+	retryNow chan struct{}
+	// abandoned counts abandoned Fn calls still running
+	abandoned atomic.Int64
+
+	lock     sync.Mutex
+	paused   bool
+	resume   chan struct{}
+	snapshot Snapshot
+
+	result Result
+	err    error
+}
+
+// Start runs Backoff in background and returns a handle to control it.
+// Canceling ctx works same as Stop.
+func (b Backoff) Start(ctx context.Context) *Runner {
+	ctx, cancel := context.WithCancelCause(ctx)
+	r := &Runner{
+		clock:    b.Clock(),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		retryNow: make(chan struct{}, 1),
+		snapshot: Snapshot{
+			State:  RunnerRunning,
+			Health: HealthUnknown,
+		},
+	}
+	go func() {
+		defer cancel(nil)
+		result, err := b._Run(ctx, r, b._MaxHistory(false))
+		r.lock.Lock()
+		r.result, r.err = result, err
+		r.snapshot.State = RunnerStopped
+		r.snapshot.WaitUntil = time.Time{}
+		r.lock.Unlock()
+		close(r.done)
+	}()
+	return r
+}
+
+// Pause stops starting new attempts, the running attempt is not affected.
+func (r *Runner) Pause() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !r.paused {
+		r.paused = true
+		r.resume = make(chan struct{})
+	}
+}
+
+func (r *Runner) Resume() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.paused {
+		r.paused = false
+		close(r.resume)
+	}
+}
+
+// RetryNow skips the current wait, or the next one if an attempt is running.
+// Attempt still waits for Resume if paused, and for Conf.RetryBudget if throttled.
+func (r *Runner) RetryNow() {
+	select {
+	case r.retryNow <- struct{}{}:
+	default:
+	}
+}
+
+// Stop cancels the running attempt with cause ErrCauseShutdown and gives up, see Done.
+func (r *Runner) Stop() {
+	r.cancel(ErrCauseShutdown)
+}
+
+// Done is closed once Backoff returned
+func (r *Runner) Done() <-chan struct{} {
+	return r.done
+}
+
+// Wait blocks until Backoff returned, Result keeps attempts limited by Conf.MaxHistory as Run does.
+func (r *Runner) Wait() (Result, error) {
+	<-r.done
+	return r.result, r.err
+}
+
+func (r *Runner) Snapshot() Snapshot {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	snapshot := r.snapshot
+	if r.paused && snapshot.State != RunnerStopped {
+		snapshot.State = RunnerPaused
+	}
+	snapshot.Abandoned = r.abandoned.Load()
+	return snapshot
+}
+
+// _WaitResume blocks until resumed if paused
+func (r *Runner) _WaitResume(ctx context.Context) error {
+	r.lock.Lock()
+	paused, resume := r.paused, r.resume
+	r.lock.Unlock()
+	if !paused {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-resume:
+		return nil
+	}
+}
+
+func (r *Runner) _Update(fn func(snapshot *Snapshot)) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	fn(&r.snapshot)
+}
+
+// _Observer keeps Snapshot updated
+func (r *Runner) _Observer() Observer {
+	return ObserverFuncs{
+		OnAttemptStart: func(ctx context.Context, attempt AttemptInfo) {
+			r._Update(func(snapshot *Snapshot) {
+				snapshot.State = RunnerRunning
+				snapshot.Attempt = attempt.Attempt
+				snapshot.WaitUntil = time.Time{}
+				snapshot.Health = HealthUnknown
+				snapshot.HealthCheck = HealthResult{}
+			})
+		},
+		OnAttemptFailure: func(ctx context.Context, attempt AttemptRecord) {
+			r._Update(func(snapshot *Snapshot) {
+				snapshot.LastError = attempt.Err
+			})
+		},
+		OnWaitScheduled: func(ctx context.Context, state RetryState) {
+			r._Update(func(snapshot *Snapshot) {
+				snapshot.State = RunnerWaiting
+				snapshot.Wait = state.Sleep
+				snapshot.WaitUntil = r.clock.Now().Add(state.Sleep)
+				snapshot.LastError = state.LastError
+			})
+		},
+		OnHealthCheckResult: func(ctx context.Context, result HealthResult) {
+			r._Update(func(snapshot *Snapshot) {
+				switch result.Status {
+				case HealthPass:
+					snapshot.Health = HealthHealthy
+				case HealthWarn:
+					snapshot.Health = HealthDegraded
+				case HealthFail:
+					snapshot.Health = HealthUnhealthy
+				}
+				snapshot.HealthCheck = result
+			})
+		},
+		OnSuccess: func(ctx context.Context, attempt AttemptRecord) {
+			r._Update(func(snapshot *Snapshot) {
+				snapshot.LastError = nil
+			})
+		},
+	}
+}
@@ -0,0 +1,165 @@
+package backoff_test
+
+import (
+	"context"
+	"github.com/Mmx233/BackoffCli/backoff"
+	"github.com/Mmx233/BackoffCli/backoff/backofftest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunner_RetryNow(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	clock := backofftest.NewFakeClock(time.Unix(0, 0))
+	fn := backofftest.NewScriptedFn(t, assert.AnError, nil)
+	runner := backoff.New(fn.Fn, backoff.Conf{
+		Logger:          backoff.NewDiscardLogger(),
+		InitialDuration: time.Minute,
+		Clock:           clock,
+	}).Start(ctx)
+
+	require.NoError(t, clock.BlockUntil(ctx, 1))
+	snapshot := runner.Snapshot()
+	assert.Equal(t, backoff.RunnerWaiting, snapshot.State)
+	assert.EqualValues(t, 1, snapshot.Attempt)
+	assert.Equal(t, time.Minute, snapshot.Wait)
+	assert.ErrorIs(t, snapshot.LastError, assert.AnError)
+
+	runner.RetryNow()
+	result, err := runner.Wait()
+	require.NoError(t, err)
+	require.Len(t, result.Attempts, 1)
+	assert.EqualValues(t, 2, result.Attempts[0].Attempt)
+	assert.Equal(t, backoff.RunnerStopped, runner.Snapshot().State)
+	assert.NoError(t, runner.Snapshot().LastError)
+	assert.Zero(t, clock.Waiters(), "skipped wait should be released")
+}
+
+func TestRunner_Pause(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	clock := backofftest.NewFakeClock(time.Unix(0, 0))
+	var count atomic.Uint32
+	runner := backoff.New(func(ctx context.Context) error {
+		count.Add(1)
+		return assert.AnError
+	}, backoff.Conf{
+		Logger:          backoff.NewDiscardLogger(),
+		InitialDuration: time.Second,
+		MaxDuration:     time.Second,
+		Clock:           clock,
+	}).Start(ctx)
+	defer runner.Stop()
+
+	require.NoError(t, clock.BlockUntil(ctx, 1))
+	runner.Pause()
+	clock.Advance(time.Second)
+	assert.Never(t, func() bool {
+		return count.Load() > 1
+	}, time.Millisecond*20, time.Millisecond, "should not start attempts while paused")
+	assert.Equal(t, backoff.RunnerPaused, runner.Snapshot().State)
+
+	runner.Resume()
+	assert.Eventually(t, func() bool {
+		return count.Load() == 2
+	}, time.Second, time.Millisecond)
+}
+
+func TestRunner_Stop(t *testing.T) {
+	t.Parallel()
+
+	causes := make(chan error, 1)
+	runner := backoff.New(func(ctx context.Context) error {
+		<-ctx.Done()
+		causes <- backoff.CancelCause(ctx)
+		return ctx.Err()
+	}, backoff.Conf{
+		Logger: backoff.NewDiscardLogger(),
+	}).Start(context.Background())
+	require.Eventually(t, func() bool {
+		return runner.Snapshot().Attempt == 1
+	}, time.Second, time.Millisecond)
+	runner.Pause()
+	runner.Stop()
+
+	_, err := runner.Wait()
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, <-causes, backoff.ErrCauseShutdown)
+	assert.Equal(t, backoff.RunnerStopped, runner.Snapshot().State)
+}
+
+func TestRunner_Health(t *testing.T) {
+	t.Parallel()
+
+	runner := backoff.New(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, backoff.Conf{
+		Logger: backoff.NewDiscardLogger(),
+		HealthChecker: func(ctx context.Context) <-chan error {
+			errChan := make(chan error, 1)
+			errChan <- nil
+			return errChan
+		},
+	}).Start(context.Background())
+	defer runner.Stop()
+
+	assert.Eventually(t, func() bool {
+		return runner.Snapshot().Health == backoff.HealthHealthy
+	}, time.Second, time.Millisecond)
+}
+
+// _StopNotifyClock notifies stopped once a timer is stopped
+type _StopNotifyClock struct {
+	*backofftest.FakeClock
+	stopped chan struct{}
+}
+
+func (c _StopNotifyClock) NewTimer(d time.Duration) (<-chan time.Time, func()) {
+	timer, stop := c.FakeClock.NewTimer(d)
+	return timer, func() {
+		stop()
+		c.stopped <- struct{}{}
+	}
+}
+
+func TestRunner_RetryNowThrottled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	clock := _StopNotifyClock{
+		FakeClock: backofftest.NewFakeClock(time.Unix(0, 0)),
+		stopped:   make(chan struct{}, 2),
+	}
+	budget := backoff.NewRetryBudget(2, 1)
+	fn := backofftest.NewScriptedFn(t, assert.AnError, nil)
+	runner := backoff.New(fn.Fn, backoff.Conf{
+		Logger:          backoff.NewDiscardLogger(),
+		InitialDuration: time.Minute,
+		Clock:           clock,
+		RetryBudget:     budget,
+		RetryBudgetWait: true,
+	}).Start(ctx)
+
+	require.NoError(t, clock.BlockUntil(ctx, 1))
+	runner.RetryNow()
+	<-clock.stopped
+	// wait skipped, then polling budget
+	require.NoError(t, clock.BlockUntil(ctx, 1))
+	assert.Len(t, fn.Calls(), 1, "should not bypass throttled budget")
+
+	budget.Success()
+	runner.RetryNow()
+	_, err := runner.Wait()
+	require.NoError(t, err)
+	fn.AssertWaits(0, time.Minute*2)
+}
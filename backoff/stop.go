@@ -0,0 +1,107 @@
+package backoff
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// RetryState describes the failed attempt when StopPolicy is called.
+type RetryState struct {
+	// Retry counts consecutive failures since Run started or restarted after success, starts from 1
+	Retry uint
+	// Elapsed since Run started
+	Elapsed time.Duration
+	// Sleep is the time going to wait before next attempt
+	Sleep     time.Duration
+	LastError error
+	// Attempts contains attempts since Run started including the current one, limited by Conf.MaxHistory
+	Attempts []AttemptRecord
+}
+
+// StopPolicy decides whether to give up after a failed attempt.
+// Return non-nil error to stop, the error will be returned by Run.
+type StopPolicy interface {
+	Stop(ctx context.Context, state RetryState) error
+}
+
+type StopPolicyFunc func(ctx context.Context, state RetryState) error
+
+func (f StopPolicyFunc) Stop(ctx context.Context, state RetryState) error {
+	return f(ctx, state)
+}
+
+// StopAny stops when any of the policies stops, nil policies are ignored.
+func StopAny(policies ...StopPolicy) StopPolicy {
+	return StopPolicyFunc(func(ctx context.Context, state RetryState) error {
+		for _, policy := range policies {
+			if policy == nil {
+				continue
+			}
+			if err := policy.Stop(ctx, state); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// StopAfterRetry stops when failures exceed the max retry count.
+type StopAfterRetry uint
+
+func (s StopAfterRetry) Stop(_ context.Context, state RetryState) error {
+	if state.Retry > uint(s) {
+		return &ErrorMaxRetryExceeded{
+			LastError: state.LastError,
+			Attempts:  slices.Clone(state.Attempts),
+		}
+	}
+	return nil
+}
+
+// StopAfterElapsed stops when total time would be exceeded after next sleep.
+type StopAfterElapsed time.Duration
+
+func (s StopAfterElapsed) Stop(_ context.Context, state RetryState) error {
+	if state.Elapsed+state.Sleep > time.Duration(s) {
+		return &ErrorMaxElapsedTimeExceeded{
+			Elapsed:        state.Elapsed,
+			MaxElapsedTime: time.Duration(s),
+			LastError:      state.LastError,
+		}
+	}
+	return nil
+}
+
+// StopBeforeDeadline stops when next attempt would start after deadline of the context.
+type StopBeforeDeadline struct{}
+
+func (StopBeforeDeadline) Stop(ctx context.Context, state RetryState) error {
+	deadline, ok := ctx.Deadline()
+	if ok && time.Now().Add(state.Sleep).After(deadline) {
+		return &ErrorWaitBeyondDeadline{
+			Deadline:  deadline,
+			Sleep:     state.Sleep,
+			LastError: state.LastError,
+		}
+	}
+	return nil
+}
+
+// StopPolicy combines MaxRetry, MaxElapsedTime, FailFast and StopPolicy in config.
+func (b Backoff) StopPolicy() StopPolicy {
+	var policies []StopPolicy
+	if b.Config.MaxRetry != 0 {
+		policies = append(policies, StopAfterRetry(b.Config.MaxRetry))
+	}
+	if b.Config.MaxElapsedTime != 0 {
+		policies = append(policies, StopAfterElapsed(b.Config.MaxElapsedTime))
+	}
+	if b.Config.FailFast {
+		policies = append(policies, StopBeforeDeadline{})
+	}
+	if b.Config.StopPolicy != nil {
+		policies = append(policies, b.Config.StopPolicy)
+	}
+	return StopAny(policies...)
+}
@@ -0,0 +1,126 @@
+package backoff
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestStopAfterRetry(t *testing.T) {
+	assert.NoError(t, StopAfterRetry(2).Stop(context.Background(), RetryState{Retry: 2}))
+	var errorMaxRetry *ErrorMaxRetryExceeded
+	assert.ErrorAs(t, StopAfterRetry(2).Stop(context.Background(), RetryState{Retry: 3}), &errorMaxRetry)
+}
+
+func TestStopAfterElapsed(t *testing.T) {
+	policy := StopAfterElapsed(time.Minute)
+	assert.NoError(t, policy.Stop(context.Background(), RetryState{
+		Elapsed: time.Second * 30,
+		Sleep:   time.Second * 30,
+	}))
+	var errorMaxElapsed *ErrorMaxElapsedTimeExceeded
+	assert.ErrorAs(t, policy.Stop(context.Background(), RetryState{
+		Elapsed:   time.Second * 30,
+		Sleep:     time.Second * 31,
+		LastError: assert.AnError,
+	}), &errorMaxElapsed)
+	assert.ErrorIs(t, errorMaxElapsed, assert.AnError, "should unwrap to last error")
+}
+
+func TestStopBeforeDeadline(t *testing.T) {
+	assert.NoError(t, StopBeforeDeadline{}.Stop(context.Background(), RetryState{Sleep: time.Hour}), "no deadline")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	assert.NoError(t, StopBeforeDeadline{}.Stop(ctx, RetryState{Sleep: time.Second}))
+	var errorWaitBeyondDeadline *ErrorWaitBeyondDeadline
+	assert.ErrorAs(t, StopBeforeDeadline{}.Stop(ctx, RetryState{Sleep: time.Hour}), &errorWaitBeyondDeadline)
+}
+
+func TestStopAny(t *testing.T) {
+	policy := StopAny(nil, StopAfterRetry(5), StopPolicyFunc(func(ctx context.Context, state RetryState) error {
+		if state.Retry == 2 {
+			return assert.AnError
+		}
+		return nil
+	}))
+	assert.NoError(t, policy.Stop(context.Background(), RetryState{Retry: 1}))
+	assert.ErrorIs(t, policy.Stop(context.Background(), RetryState{Retry: 2}), assert.AnError)
+	var errorMaxRetry *ErrorMaxRetryExceeded
+	assert.ErrorAs(t, policy.Stop(context.Background(), RetryState{Retry: 6}), &errorMaxRetry)
+}
+
+func TestBackoff_StopPolicy(t *testing.T) {
+	t.Parallel()
+
+	logger := _NewDiscardLogger()
+
+	var count uint
+	err := New(func(ctx context.Context) error {
+		count++
+		return assert.AnError
+	}, Conf{
+		Logger:          logger,
+		InitialDuration: time.Millisecond,
+		MaxDuration:     time.Millisecond,
+		MaxRetry:        10,
+		StopPolicy: StopPolicyFunc(func(ctx context.Context, state RetryState) error {
+			if state.Retry == 3 {
+				return Permanent(state.LastError)
+			}
+			return nil
+		}),
+	}).Run(context.Background())
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, uint(3), count)
+}
+
+func TestBackoff_FailFast(t *testing.T) {
+	t.Parallel()
+
+	logger := _NewDiscardLogger()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	err := New(func(ctx context.Context) error {
+		return assert.AnError
+	}, Conf{
+		Logger:          logger,
+		InitialDuration: time.Hour,
+		MaxDuration:     time.Hour,
+		FailFast:        true,
+	}).Run(ctx)
+	var errorWaitBeyondDeadline *ErrorWaitBeyondDeadline
+	assert.ErrorAs(t, err, &errorWaitBeyondDeadline)
+}
+
+func TestBackoff_AttemptTimeout(t *testing.T) {
+	t.Parallel()
+
+	logger := _NewDiscardLogger()
+
+	var count uint
+	err := New(func(ctx context.Context) error {
+		count++
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			t.Error("attempt timeout not taking effect")
+			return nil
+		}
+	}, Conf{
+		Logger:          logger,
+		InitialDuration: time.Millisecond,
+		MaxDuration:     time.Millisecond,
+		MaxRetry:        1,
+		AttemptTimeout:  time.Millisecond * 5,
+	}).Run(context.Background())
+
+	var errorMaxRetry *ErrorMaxRetryExceeded
+	require.ErrorAs(t, err, &errorMaxRetry)
+	assert.ErrorIs(t, errorMaxRetry.LastError, context.DeadlineExceeded)
+	assert.Equal(t, uint(2), count)
+}
@@ -0,0 +1,122 @@
+package backoff
+
+import (
+	"math"
+	"time"
+)
+
+// Strategy calculates the next wait time from the last one.
+// Result will be capped by Conf.MaxDuration.
+type Strategy interface {
+	Next(last time.Duration) time.Duration
+}
+
+type StrategyFunc func(last time.Duration) time.Duration
+
+func (f StrategyFunc) Next(last time.Duration) time.Duration {
+	return f(last)
+}
+
+// FactorStrategy is the default strategy.
+// $Next = ($Last + InterConstFactor) * (2 ^ ExponentFactor) + OuterConstFactor
+type FactorStrategy struct {
+	ExponentFactor   int
+	InterConstFactor time.Duration
+	OuterConstFactor time.Duration
+}
+
+func (s FactorStrategy) Next(last time.Duration) time.Duration {
+	return (last+s.InterConstFactor)<<s.ExponentFactor + s.OuterConstFactor
+}
+
+// ConstantStrategy always waits InitialDuration.
+type ConstantStrategy struct{}
+
+func (ConstantStrategy) Next(last time.Duration) time.Duration {
+	return last
+}
+
+// LinearStrategy adds Step to wait time on every retry.
+type LinearStrategy struct {
+	Step time.Duration
+}
+
+func (s LinearStrategy) Next(last time.Duration) time.Duration {
+	return last + s.Step
+}
+
+// ExponentialStrategy multiplies wait time by Multiplier on every retry.
+// $Next = $Last * Multiplier + Const
+type ExponentialStrategy struct {
+	// Multiplier default 2
+	Multiplier float64
+	Const      time.Duration
+}
+
+func (s ExponentialStrategy) Next(last time.Duration) time.Duration {
+	multiplier := s.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+	return _FloatDuration(float64(last)*multiplier + float64(s.Const))
+}
+
+// FibonacciStrategy produces Unit * 1, 2, 3, 5, 8 ... when starts with Unit.
+// Unit should be same as InitialDuration, if Unit is zero, wait time
+// grows by golden ratio without rounding.
+type FibonacciStrategy struct {
+	Unit time.Duration
+}
+
+func (s FibonacciStrategy) Next(last time.Duration) time.Duration {
+	next := float64(last) * math.Phi
+	if s.Unit > 0 {
+		next = math.Round(next/float64(s.Unit)) * float64(s.Unit)
+	}
+	return _FloatDuration(next)
+}
+
+// PolynomialStrategy produces Unit * n ^ Degree for the nth wait when starts with Unit.
+// Unit should be same as InitialDuration.
+type PolynomialStrategy struct {
+	Unit   time.Duration
+	Degree float64
+}
+
+func (s PolynomialStrategy) Next(last time.Duration) time.Duration {
+	if s.Degree <= 0 {
+		return last
+	}
+	root := math.Pow(float64(last), 1/s.Degree) + math.Pow(float64(s.Unit), 1/s.Degree)
+	return _FloatDuration(math.Pow(root, s.Degree))
+}
+
+func _FloatDuration(d float64) time.Duration {
+	if d >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	if d < 0 {
+		return 0
+	}
+	return time.Duration(math.Round(d))
+}
+
+// WaitPolicy calculates wait time apart from the main settings of Conf,
+// zero values fall back to InitialDuration, MaxDuration and Strategy of Conf.
+type WaitPolicy struct {
+	InitialDuration time.Duration
+	MaxDuration     time.Duration
+	Strategy        Strategy
+}
+
+// Next returns wait time after wait, capped at MaxDuration
+func (p WaitPolicy) Next(wait time.Duration) time.Duration {
+	if wait < p.MaxDuration {
+		wait = p.Strategy.Next(wait)
+		if wait > p.MaxDuration {
+			return p.MaxDuration
+		}
+		return wait
+	}
+	return p.MaxDuration
+}
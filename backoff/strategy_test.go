@@ -0,0 +1,57 @@
+package backoff
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+	"time"
+)
+
+func _StrategySequence(s Strategy, initial time.Duration, n int) []time.Duration {
+	seq := make([]time.Duration, 0, n)
+	wait := initial
+	for range n {
+		seq = append(seq, wait)
+		wait = s.Next(wait)
+	}
+	return seq
+}
+
+func TestFactorStrategy(t *testing.T) {
+	assert.Equal(t, []time.Duration{
+		time.Second, time.Second * 2, time.Second * 4, time.Second * 8,
+	}, _StrategySequence(FactorStrategy{ExponentFactor: 1}, time.Second, 4))
+}
+
+func TestConstantStrategy(t *testing.T) {
+	assert.Equal(t, []time.Duration{
+		time.Second, time.Second, time.Second,
+	}, _StrategySequence(ConstantStrategy{}, time.Second, 3))
+}
+
+func TestLinearStrategy(t *testing.T) {
+	assert.Equal(t, []time.Duration{
+		time.Second, time.Second * 3, time.Second * 5,
+	}, _StrategySequence(LinearStrategy{Step: time.Second * 2}, time.Second, 3))
+}
+
+func TestExponentialStrategy(t *testing.T) {
+	assert.Equal(t, []time.Duration{
+		time.Second * 2, time.Second * 3, time.Millisecond * 4500,
+	}, _StrategySequence(ExponentialStrategy{Multiplier: 1.5}, time.Second*2, 3))
+
+	assert.Equal(t, time.Duration(math.MaxInt64), ExponentialStrategy{Multiplier: 2}.Next(math.MaxInt64/2+1), "overflow not handled")
+	assert.Equal(t, time.Second*2, ExponentialStrategy{}.Next(time.Second), "zero multiplier should default to 2")
+}
+
+func TestFibonacciStrategy(t *testing.T) {
+	assert.Equal(t, []time.Duration{
+		time.Second, time.Second * 2, time.Second * 3, time.Second * 5, time.Second * 8, time.Second * 13,
+	}, _StrategySequence(FibonacciStrategy{Unit: time.Second}, time.Second, 6))
+}
+
+func TestPolynomialStrategy(t *testing.T) {
+	assert.Equal(t, []time.Duration{
+		time.Second, time.Second * 4, time.Second * 9, time.Second * 16,
+	}, _StrategySequence(PolynomialStrategy{Unit: time.Second, Degree: 2}, time.Second, 4))
+}
@@ -0,0 +1,208 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// Transport retries idempotent requests with backoff on network errors and retryable status codes.
+// Once retry gave up on retryable status, the last response is returned as is, with body still readable.
+// Other errors of Base, e.g. TLS certificate errors or unsupported protocol scheme, are permanent.
+// Conf.HedgeDelay is supported, responses of losing hedged requests are drained and closed.
+type Transport struct {
+	Base http.RoundTripper
+	Conf Conf
+
+	retryStatus map[int]struct{}
+	isRetryable func(req *http.Request) bool
+}
+
+type TransportOption func(t *Transport)
+
+// WithRetryStatus replaces status codes to be retried, default 429, 502, 503 and 504
+func WithRetryStatus(codes ...int) TransportOption {
+	return func(t *Transport) {
+		t.retryStatus = make(map[int]struct{}, len(codes))
+		for _, code := range codes {
+			t.retryStatus[code] = struct{}{}
+		}
+	}
+}
+
+// WithRetryableRequest replaces the check of whether a request can be retried,
+// default only idempotent requests are retried. Requests with body not replayable
+// through GetBody are never retried.
+func WithRetryableRequest(fn func(req *http.Request) bool) TransportOption {
+	return func(t *Transport) {
+		t.isRetryable = fn
+	}
+}
+
+// DefaultTransportMaxRetry is used by NewTransport if Conf.MaxRetry is 0,
+// so requests without deadline do not retry forever
+const DefaultTransportMaxRetry = 3
+
+// NewTransport wraps base with retry, nil base means http.DefaultTransport.
+// Conf.MaxRetry defaults to DefaultTransportMaxRetry.
+func NewTransport(base http.RoundTripper, conf Conf, opts ...TransportOption) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if conf.MaxRetry == 0 {
+		conf.MaxRetry = DefaultTransportMaxRetry
+	}
+	t := &Transport{
+		Base: base,
+		Conf: conf,
+		retryStatus: map[int]struct{}{
+			http.StatusTooManyRequests:    {},
+			http.StatusBadGateway:         {},
+			http.StatusServiceUnavailable: {},
+			http.StatusGatewayTimeout:     {},
+		},
+		isRetryable: IsIdempotentRequest,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// IsIdempotentRequest reports whether req is idempotent, same rule as net/http retrying requests
+func IsIdempotentRequest(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	if _, ok := req.Header["Idempotency-Key"]; ok {
+		return true
+	}
+	if _, ok := req.Header["X-Idempotency-Key"]; ok {
+		return true
+	}
+	return false
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	if (hasBody && req.GetBody == nil) || !t.isRetryable(req) {
+		return t.Base.RoundTrip(req)
+	}
+
+	// last response of retryable status, returned once retry gave up.
+	// It's cleared by later failures without response.
+	var lock sync.Mutex
+	var last *http.Response
+	var returned bool
+	keepLast := func(resp *http.Response) {
+		lock.Lock()
+		defer lock.Unlock()
+		if returned {
+			// late hedged call
+			if resp != nil {
+				_DiscardResponse(resp)
+			}
+			return
+		}
+		if last != nil {
+			_DiscardResponse(last)
+		}
+		last = resp
+	}
+
+	resp, err := DoWithDiscard(req.Context(), t.Conf, func(ctx context.Context) (*http.Response, error) {
+		// context of attempt is canceled once returned, but response body is read later.
+		// Request context only follows the attempt until response header received.
+		reqCtx, cancel := context.WithCancel(req.Context())
+		stop := context.AfterFunc(ctx, cancel)
+
+		attemptReq := req.Clone(reqCtx)
+		// only the first call sends the original body, others including hedged calls need a fresh one
+		if attempt, _ := AttemptFromContext(ctx); hasBody && (attempt.Attempt > 1 || attempt.Hedge > 0) {
+			body, err := req.GetBody()
+			if err != nil {
+				stop()
+				cancel()
+				keepLast(nil)
+				return nil, Permanent(err)
+			}
+			attemptReq.Body = body
+		}
+
+		resp, err := t.Base.RoundTrip(attemptReq)
+		if !stop() {
+			// attempt canceled before response returned
+			if err == nil {
+				_ = resp.Body.Close()
+				err = ctx.Err()
+			}
+		}
+		if err != nil {
+			cancel()
+			keepLast(nil)
+			return nil, _TransportError(err)
+		}
+
+		resp.Body = &_CancelBody{ReadCloser: resp.Body, cancel: cancel}
+		if _, ok := t.retryStatus[resp.StatusCode]; ok {
+			keepLast(resp)
+			return nil, NewErrorUnexpectedHttpStatus(resp)
+		}
+		return resp, nil
+	}, _DiscardResponse)
+
+	lock.Lock()
+	defer lock.Unlock()
+	returned = true
+	if last == nil {
+		return resp, err
+	}
+	var errorUnexpectedHttpStatus *ErrorUnexpectedHttpStatus
+	if err != nil && errors.As(err, &errorUnexpectedHttpStatus) && req.Context().Err() == nil {
+		return last, nil
+	}
+	_DiscardResponse(last)
+	return resp, err
+}
+
+// _TransportError makes err permanent unless it's a network error, EOF or cancellation
+func _TransportError(err error) error {
+	var netErr net.Error
+	switch {
+	case errors.As(err, &netErr),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, context.Canceled):
+		return err
+	}
+	return Permanent(err)
+}
+
+// _DiscardResponse drains a little of body to reuse the connection and closes it
+func _DiscardResponse(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	_ = resp.Body.Close()
+}
+
+// CloseIdleConnections forwards to Base if supported
+func (t *Transport) CloseIdleConnections() {
+	if closer, ok := t.Base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// _CancelBody cancels request context once response body closed
+type _CancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *_CancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
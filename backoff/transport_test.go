@@ -0,0 +1,271 @@
+package backoff
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func _NewTransportClient(opts ...TransportOption) *http.Client {
+	return &http.Client{
+		Transport: NewTransport(nil, Conf{
+			Logger:          _NewDiscardLogger(),
+			InitialDuration: time.Millisecond,
+			MaxRetry:        3,
+		}, opts...),
+	}
+}
+
+func TestTransport_RetryStatus(t *testing.T) {
+	t.Parallel()
+
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if count.Add(1) < 3 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = rw.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	resp, err := _NewTransportClient().Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, "body should be readable after retry returned")
+	assert.Equal(t, "ok", string(body))
+	assert.EqualValues(t, 3, count.Load())
+}
+
+func TestTransport_GiveUp(t *testing.T) {
+	t.Parallel()
+
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("X-Count", strconv.Itoa(int(count.Add(1))))
+		rw.WriteHeader(http.StatusTooManyRequests)
+		_, _ = rw.Write([]byte("slow down"))
+	}))
+	defer server.Close()
+
+	resp, err := _NewTransportClient().Get(server.URL)
+	require.NoError(t, err, "last response should be returned once gave up")
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "4", resp.Header.Get("X-Count"))
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "slow down", string(body))
+	assert.EqualValues(t, 4, count.Load())
+}
+
+func TestTransport_DefaultMaxRetry(t *testing.T) {
+	t.Parallel()
+
+	var count atomic.Int32
+	client := &http.Client{
+		Transport: NewTransport(_RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			count.Add(1)
+			return &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Header:     http.Header{},
+				Body:       http.NoBody,
+			}, nil
+		}), Conf{
+			Logger:          _NewDiscardLogger(),
+			InitialDuration: time.Millisecond,
+		}),
+	}
+	resp, err := client.Get("http://127.0.0.1/")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.EqualValues(t, DefaultTransportMaxRetry+1, count.Load(), "should not retry forever by default")
+}
+
+func TestTransport_RetryAfter(t *testing.T) {
+	t.Parallel()
+
+	var count atomic.Int32
+	var firstAt time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if count.Add(1) == 1 {
+			firstAt = time.Now()
+			rw.Header().Set("Retry-After", "1")
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.GreaterOrEqual(t, time.Since(firstAt), time.Second, "Retry-After not honored")
+	}))
+	defer server.Close()
+
+	resp, err := _NewTransportClient().Get(server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.EqualValues(t, 2, count.Load())
+}
+
+func TestTransport_Body(t *testing.T) {
+	t.Parallel()
+
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		assert.Equal(t, "payload", string(body), "body should be replayed")
+		if count.Add(1) < 2 {
+			rw.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodPut, server.URL, bytes.NewReader([]byte("payload")))
+	require.NoError(t, err)
+	resp, err := _NewTransportClient().Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.EqualValues(t, 2, count.Load())
+}
+
+func TestTransport_HedgedBody(t *testing.T) {
+	t.Parallel()
+
+	payload := bytes.Repeat([]byte("payload"), 1<<17)
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		assert.Equal(t, len(payload), len(body), "each hedged call should send the whole body")
+		if count.Add(1) == 1 {
+			// slow until canceled by the hedged call
+			<-req.Context().Done()
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: NewTransport(nil, Conf{
+			Logger:     _NewDiscardLogger(),
+			MaxRetry:   3,
+			HedgeDelay: time.Millisecond * 50,
+		}),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, server.URL, bytes.NewReader(payload))
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 2, count.Load())
+}
+
+func TestTransport_NotRetryable(t *testing.T) {
+	t.Parallel()
+
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		count.Add(1)
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	// not idempotent
+	resp, err := _NewTransportClient().Post(server.URL, "text/plain", bytes.NewReader([]byte("payload")))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	// body can not be replayed
+	req, err := http.NewRequest(http.MethodPut, server.URL, io.NopCloser(bytes.NewReader([]byte("payload"))))
+	require.NoError(t, err)
+	resp, err = _NewTransportClient().Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	// status not configured
+	resp, err = _NewTransportClient(WithRetryStatus(http.StatusInternalServerError)).Get(server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.EqualValues(t, 3, count.Load())
+}
+
+func TestTransport_NetworkError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	var count atomic.Int32
+	_, err := _NewTransportClient(WithRetryableRequest(func(req *http.Request) bool {
+		count.Add(1)
+		return true
+	})).Get(url)
+	assert.Error(t, err)
+	var errorMaxRetry *ErrorMaxRetryExceeded
+	assert.ErrorAs(t, err, &errorMaxRetry)
+	require.Len(t, errorMaxRetry.Attempts, 4, "all consecutive failures are kept")
+	assert.EqualValues(t, 4, errorMaxRetry.Attempts[3].Attempt)
+	assert.EqualValues(t, 1, count.Load())
+}
+
+type _RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f _RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTransport_PermanentError(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		err     error
+		retried bool
+	}{
+		{"unexpected eof", io.ErrUnexpectedEOF, true},
+		{"network error", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, true},
+		{"certificate", x509.UnknownAuthorityError{}, false},
+		{"unsupported scheme", errors.New(`unsupported protocol scheme "ftp"`), false},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var count atomic.Int32
+			client := &http.Client{
+				Transport: NewTransport(_RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+					count.Add(1)
+					return nil, testCase.err
+				}), Conf{
+					Logger:          _NewDiscardLogger(),
+					InitialDuration: time.Millisecond,
+					MaxRetry:        3,
+				}),
+			}
+			_, err := client.Get("http://127.0.0.1/")
+			assert.ErrorIs(t, err, testCase.err)
+			if testCase.retried {
+				assert.EqualValues(t, 4, count.Load())
+			} else {
+				var errorPermanent *ErrorPermanent
+				assert.ErrorAs(t, err, &errorPermanent)
+				assert.EqualValues(t, 1, count.Load())
+			}
+		})
+	}
+}
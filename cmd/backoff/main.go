@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"github.com/Mmx233/BackoffCli/backoff"
+	"github.com/Mmx233/BackoffCli/backoff/logrusadapter"
 	_backoff "github.com/Mmx233/BackoffCli/internal/backoff"
 	"github.com/Mmx233/BackoffCli/internal/config"
 	"github.com/Mmx233/BackoffCli/internal/singleton"
@@ -11,7 +12,6 @@ import (
 	nested "github.com/antonfisher/nested-logrus-formatter"
 	log "github.com/sirupsen/logrus"
 	"os"
-	"os/exec"
 	"os/signal"
 	"path"
 	"strings"
@@ -31,9 +31,9 @@ func main() {
 		TimestampFormat: "2006-01-02 15:04:05",
 	})
 
-	quit := make(chan os.Signal)
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	quit := make(chan os.Signal, 1)
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
 	quitProcess := func() {
 		select {
 		case <-ctx.Done():
@@ -44,32 +44,37 @@ func main() {
 	_singleton, singletonInstance := singleton.New(ctx, logger.WithField(config.LogKeyComponent, "singleton"), quitProcess)
 	defer singletonInstance.Shutdown()
 
-	backoffConf, err := config.Config.NewBackoffConf(logger.WithField(config.LogKeyComponent, "backoff")), error(nil)
-	backoffConf.HealthChecker, err = _backoff.NewHealthCheckFn(logger.WithField(config.LogKeyComponent, "health_checker"))
+	backoffConf, err := config.Config.NewBackoffConf(logrusadapter.New(logger.WithField(config.LogKeyComponent, "backoff"))), error(nil)
+	backoffConf.HealthResultChecker, err = _backoff.NewHealthCheckFn(logrusadapter.New(logger.WithField(config.LogKeyComponent, "health_checker")))
 	if err != nil {
 		logger.Warnln("create health checker failed, proceed without health check:", err)
 	}
 
-	lastCmd := make(chan *exec.Cmd, 1)
-	backoffInstance := backoff.NewInstance(_backoff.NewBackoffFn(lastCmd, _singleton), backoffConf)
+	exitPolicy, err := _backoff.NewExitPolicy()
+	if err != nil {
+		logger.Fatalln("parse exit policy failed:", err)
+	}
+
+	lastCmd := make(chan *_backoff.Command, 1)
+	backoffInstance := backoff.NewInstance(_backoff.NewBackoffFn(lastCmd, _singleton, exitPolicy), backoffConf)
+	runner := backoffInstance.Start(ctx)
+	singletonInstance.Runner.Store(runner)
 	go func() {
-		if err := backoffInstance.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		if _, err := runner.Wait(); err != nil && !errors.Is(err, context.Canceled) {
 			logger.Errorln("backoff run failed:", err)
-			quitProcess()
 		}
+		quitProcess()
 	}()
 
 	signal.Notify(quit, os.Interrupt, os.Kill, syscall.SIGTERM)
 	<-quit
 	logger.Infoln("Shutdown...")
-	cancel()
+	cancel(backoff.ErrCauseShutdown)
 
 	select {
 	case cmd := <-lastCmd:
-		if cmd.Process != nil {
-			_ = cmd.Process.Kill()
-			_, _ = cmd.Process.Wait()
-		}
+		// program is interrupted and killed after shutdown timeout
+		<-cmd.Done
 	default:
 	}
 }
@@ -2,6 +2,8 @@ package backoff
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/Mmx233/BackoffCli/backoff"
 	"github.com/Mmx233/BackoffCli/internal/config"
 	"github.com/Mmx233/BackoffCli/internal/singleton"
@@ -10,7 +12,13 @@ import (
 	"strings"
 )
 
-func NewBackoffFn(lastCmd chan *exec.Cmd, _singleton singleton.DoSingleton) backoff.Fn {
+// Command is the program started by an attempt, Done is closed once it exited or failed to start
+type Command struct {
+	*exec.Cmd
+	Done chan struct{}
+}
+
+func NewBackoffFn(lastCmd chan *Command, _singleton singleton.DoSingleton, exitPolicy *ExitPolicy) backoff.Fn {
 	return func(ctx context.Context) error {
 		if err := _singleton(); err != nil {
 			return err
@@ -25,11 +33,56 @@ func NewBackoffFn(lastCmd chan *exec.Cmd, _singleton singleton.DoSingleton) back
 		defer cancel()
 
 		parts := strings.Fields(config.Config.Path)
-		cmd := exec.CommandContext(ctx, parts[0], parts[1:]...)
+		cmd := &Command{
+			Cmd:  exec.CommandContext(ctx, parts[0], parts[1:]...),
+			Done: make(chan struct{}),
+		}
+		defer close(cmd.Done)
 		cmd.Stdin = os.Stdin
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
+		cmd.Env = append(os.Environ(), AttemptEnv(ctx)...)
+		cmd.Cancel = func() error {
+			if errors.Is(backoff.CancelCause(ctx), backoff.ErrCauseShutdown) {
+				// let program exit gracefully, killed after WaitDelay
+				if err := cmd.Process.Signal(os.Interrupt); err == nil {
+					return nil
+				}
+			}
+			return cmd.Process.Kill()
+		}
+		cmd.WaitDelay = config.Config.ShutdownTimeout
 		lastCmd <- cmd
-		return cmd.Run()
+		if err := cmd.Start(); err != nil {
+			return exitPolicy.SpawnError(err)
+		}
+		err := cmd.Wait()
+		if ctx.Err() != nil {
+			// killed by health check or parent, exit status is meaningless
+			return err
+		}
+		return exitPolicy.ExitError(err)
+	}
+}
+
+// AttemptEnv passes backoff.AttemptInfo to the program
+func AttemptEnv(ctx context.Context) []string {
+	attempt, ok := backoff.AttemptFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	env := []string{
+		fmt.Sprintf("%s=%d", config.EnvAttempt, attempt.Attempt),
+		fmt.Sprintf("%s=%s", config.EnvLastWait, attempt.Wait),
+	}
+
+	var exitErr *exec.ExitError
+	var errorMinUptime *backoff.ErrorMinUptime
+	switch {
+	case errors.As(attempt.LastError, &exitErr):
+		env = append(env, fmt.Sprintf("%s=%d", config.EnvLastExitCode, exitErr.ExitCode()))
+	case attempt.Attempt > 1 && (attempt.LastError == nil || errors.As(attempt.LastError, &errorMinUptime)):
+		env = append(env, fmt.Sprintf("%s=0", config.EnvLastExitCode))
 	}
+	return env
 }
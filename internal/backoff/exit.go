@@ -0,0 +1,143 @@
+package backoff
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Mmx233/BackoffCli/backoff"
+	"github.com/Mmx233/BackoffCli/internal/config"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+var signals = map[string]syscall.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGILL":  syscall.SIGILL,
+	"SIGTRAP": syscall.SIGTRAP,
+	"SIGABRT": syscall.SIGABRT,
+	"SIGBUS":  syscall.SIGBUS,
+	"SIGFPE":  syscall.SIGFPE,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGSEGV": syscall.SIGSEGV,
+	"SIGPIPE": syscall.SIGPIPE,
+	"SIGALRM": syscall.SIGALRM,
+	"SIGTERM": syscall.SIGTERM,
+}
+
+type ExitPolicy struct {
+	Success       map[int]struct{}
+	NoRetry       map[int]struct{}
+	NoRetrySignal map[syscall.Signal]struct{}
+	// If Retry or RetrySignal is not empty, only exit codes and signals in them will be retried
+	Retry       map[int]struct{}
+	RetrySignal map[syscall.Signal]struct{}
+
+	RetrySpawnFailure bool
+}
+
+func NewExitPolicy() (*ExitPolicy, error) {
+	policy, err := ParseExitPolicy(config.Config.ExitSuccess, config.Config.ExitNoRetry, config.Config.ExitRetry)
+	if err != nil {
+		return nil, err
+	}
+	policy.RetrySpawnFailure = config.Config.ExitRetrySpawnFailure
+	return policy, nil
+}
+
+// ParseExitPolicy parses values of --exit.success, --exit.no_retry and --exit.retry
+func ParseExitPolicy(success, noRetry, retry []string) (*ExitPolicy, error) {
+	policy := ExitPolicy{
+		Success:       make(map[int]struct{}),
+		NoRetry:       make(map[int]struct{}),
+		NoRetrySignal: make(map[syscall.Signal]struct{}),
+		Retry:         make(map[int]struct{}),
+		RetrySignal:   make(map[syscall.Signal]struct{}),
+	}
+	for _, values := range [...]struct {
+		flag    string
+		values  []string
+		codes   map[int]struct{}
+		signals map[syscall.Signal]struct{}
+	}{
+		{"exit.success", success, policy.Success, nil},
+		{"exit.no_retry", noRetry, policy.NoRetry, policy.NoRetrySignal},
+		{"exit.retry", retry, policy.Retry, policy.RetrySignal},
+	} {
+		for _, value := range values.values {
+			for _, item := range strings.Split(value, ",") {
+				item = strings.TrimSpace(item)
+				if item == "" {
+					continue
+				}
+				if code, err := strconv.Atoi(item); err == nil {
+					values.codes[code] = struct{}{}
+					continue
+				}
+				if values.signals != nil {
+					name := strings.ToUpper(item)
+					if !strings.HasPrefix(name, "SIG") {
+						name = "SIG" + name
+					}
+					if signal, ok := signals[name]; ok {
+						values.signals[signal] = struct{}{}
+						continue
+					}
+				}
+				return nil, fmt.Errorf("invalid value '%s' of --%s", item, values.flag)
+			}
+		}
+	}
+	return &policy, nil
+}
+
+// SpawnError handles errors happened before process started.
+func (p ExitPolicy) SpawnError(err error) error {
+	if p.RetrySpawnFailure {
+		return err
+	}
+	return backoff.Permanent(err)
+}
+
+// ExitError handles errors returned by waiting a started process.
+// Processes killed by signal are never treated as success, they are retried
+// unless the signal is in NoRetrySignal, or RetrySignal is set without it.
+func (p ExitPolicy) ExitError(err error) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		if _, ok := p.NoRetrySignal[status.Signal()]; ok {
+			return backoff.Permanent(err)
+		}
+		if p._RetryOnly() {
+			if _, ok := p.RetrySignal[status.Signal()]; !ok {
+				return backoff.Permanent(err)
+			}
+		}
+		return err
+	}
+
+	code := exitErr.ExitCode()
+	if _, ok := p.Success[code]; ok {
+		return nil
+	}
+	if _, ok := p.NoRetry[code]; ok {
+		return backoff.Permanent(err)
+	}
+	if p._RetryOnly() {
+		if _, ok := p.Retry[code]; !ok {
+			return backoff.Permanent(err)
+		}
+	}
+	return err
+}
+
+// _RetryOnly returns true if only exit codes and signals in Retry and RetrySignal are retried
+func (p ExitPolicy) _RetryOnly() bool {
+	return len(p.Retry) != 0 || len(p.RetrySignal) != 0
+}
@@ -0,0 +1,85 @@
+package backoff
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"syscall"
+	"testing"
+)
+
+func TestParseExitPolicy(t *testing.T) {
+	t.Parallel()
+
+	for _, c := range []struct {
+		name          string
+		success       []string
+		noRetry       []string
+		retry         []string
+		expected      ExitPolicy
+		expectedError string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:    "codes",
+			success: []string{"0, 2"},
+			noRetry: []string{"3", "4,"},
+			retry:   []string{"5"},
+			expected: ExitPolicy{
+				Success: map[int]struct{}{0: {}, 2: {}},
+				NoRetry: map[int]struct{}{3: {}, 4: {}},
+				Retry:   map[int]struct{}{5: {}},
+			},
+		},
+		{
+			name:    "signals",
+			noRetry: []string{"SIGKILL,term"},
+			retry:   []string{"1,sigsegv"},
+			expected: ExitPolicy{
+				NoRetrySignal: map[syscall.Signal]struct{}{syscall.SIGKILL: {}, syscall.SIGTERM: {}},
+				Retry:         map[int]struct{}{1: {}},
+				RetrySignal:   map[syscall.Signal]struct{}{syscall.SIGSEGV: {}},
+			},
+		},
+		{
+			name:          "signal not allowed",
+			success:       []string{"SIGKILL"},
+			expectedError: "invalid value 'SIGKILL' of --exit.success",
+		},
+		{
+			name:          "unknown signal",
+			noRetry:       []string{"SIGFOO"},
+			expectedError: "invalid value 'SIGFOO' of --exit.no_retry",
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			policy, err := ParseExitPolicy(c.success, c.noRetry, c.retry)
+			if c.expectedError != "" {
+				assert.EqualError(t, err, c.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, policy.Success, len(c.expected.Success))
+			for code := range c.expected.Success {
+				assert.Contains(t, policy.Success, code)
+			}
+			assert.Len(t, policy.NoRetry, len(c.expected.NoRetry))
+			for code := range c.expected.NoRetry {
+				assert.Contains(t, policy.NoRetry, code)
+			}
+			assert.Len(t, policy.NoRetrySignal, len(c.expected.NoRetrySignal))
+			for signal := range c.expected.NoRetrySignal {
+				assert.Contains(t, policy.NoRetrySignal, signal)
+			}
+			assert.Len(t, policy.Retry, len(c.expected.Retry))
+			for code := range c.expected.Retry {
+				assert.Contains(t, policy.Retry, code)
+			}
+			assert.Len(t, policy.RetrySignal, len(c.expected.RetrySignal))
+			for signal := range c.expected.RetrySignal {
+				assert.Contains(t, policy.RetrySignal, signal)
+			}
+		})
+	}
+}
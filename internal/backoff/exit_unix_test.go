@@ -0,0 +1,70 @@
+//go:build !windows
+
+package backoff
+
+import (
+	"errors"
+	"github.com/Mmx233/BackoffCli/backoff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os/exec"
+	"testing"
+)
+
+func _RunExit(t *testing.T, script string) error {
+	err := exec.Command("sh", "-c", script).Run()
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	return err
+}
+
+func TestExitPolicy_ExitError(t *testing.T) {
+	t.Parallel()
+
+	exit3 := _RunExit(t, "exit 3")
+	killed := _RunExit(t, "kill -TERM $$")
+	const (
+		retry     = "retry"
+		permanent = "permanent"
+		success   = "success"
+	)
+
+	for _, c := range []struct {
+		name     string
+		success  []string
+		noRetry  []string
+		retry    []string
+		err      error
+		expected string
+	}{
+		{name: "default code", err: exit3, expected: retry},
+		{name: "default signal", err: killed, expected: retry},
+		{name: "success code", success: []string{"3"}, err: exit3, expected: success},
+		{name: "no retry code", noRetry: []string{"3"}, err: exit3, expected: permanent},
+		{name: "no retry signal", noRetry: []string{"SIGTERM"}, err: killed, expected: permanent},
+		{name: "retry code", retry: []string{"3"}, err: exit3, expected: retry},
+		{name: "retry other code", retry: []string{"1"}, err: exit3, expected: permanent},
+		{name: "retry only codes kills signal", retry: []string{"3"}, err: killed, expected: permanent},
+		{name: "retry signal", retry: []string{"SIGTERM"}, err: killed, expected: retry},
+		{name: "retry signal only", retry: []string{"SIGTERM"}, err: exit3, expected: permanent},
+		{name: "not exit error", err: errors.New("wait failed"), expected: retry},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			policy, err := ParseExitPolicy(c.success, c.noRetry, c.retry)
+			require.NoError(t, err)
+
+			err = policy.ExitError(c.err)
+			var permanentErr *backoff.ErrorPermanent
+			switch c.expected {
+			case success:
+				assert.NoError(t, err)
+			case permanent:
+				assert.ErrorAs(t, err, &permanentErr)
+				assert.ErrorIs(t, err, c.err)
+			case retry:
+				assert.False(t, errors.As(err, &permanentErr), "should be retried")
+				assert.ErrorIs(t, err, c.err)
+			}
+		})
+	}
+}
@@ -4,14 +4,14 @@ import (
 	"crypto/tls"
 	"github.com/Mmx233/BackoffCli/backoff"
 	"github.com/Mmx233/BackoffCli/internal/config"
-	log "github.com/sirupsen/logrus"
 	"net"
 	"net/http"
 	"net/url"
 )
 
-func NewHealthCheckFn(logger log.FieldLogger) (backoff.HealthChecker, error) {
+func NewHealthCheckFn(logger backoff.Logger) (backoff.HealthResultChecker, error) {
 	var healthCheckFn backoff.ProbeHealthCheckFn
+	var name string
 
 	switch {
 	case config.Config.TcpAddr != "":
@@ -19,6 +19,7 @@ func NewHealthCheckFn(logger log.FieldLogger) (backoff.HealthChecker, error) {
 		if err != nil {
 			return nil, err
 		}
+		name = "tcp"
 		healthCheckFn = backoff.NewTcpProbeHealthCheckFn(backoff.TcpProbeHealthCheckConfig{
 			Addr:    config.Config.TcpAddr,
 			Timeout: config.Config.TcpTimeout,
@@ -54,6 +55,7 @@ func NewHealthCheckFn(logger log.FieldLogger) (backoff.HealthChecker, error) {
 			}
 		}
 
+		name = "http"
 		healthCheckFn = backoff.NewHttpProbeHealthCheckFn(backoff.HttpProbeHealthCheckConfig{
 			Client:         httpClient,
 			Header:         header,
@@ -67,7 +69,8 @@ func NewHealthCheckFn(logger log.FieldLogger) (backoff.HealthChecker, error) {
 	}
 
 	if healthCheckFn != nil {
-		return backoff.NewProbeHealthChecker(healthCheckFn, backoff.ProbeHealthCheckerConfig{
+		return backoff.NewProbeHealthResultChecker(healthCheckFn, backoff.ProbeHealthCheckerConfig{
+			Name:             name,
 			Logger:           logger,
 			CheckInterval:    config.Config.ProbeInterval,
 			InitialDelay:     config.Config.ProbeInitialDelay,
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"github.com/Mmx233/BackoffCli/backoff"
 	"github.com/alecthomas/kingpin/v2"
 )
 
@@ -11,16 +12,45 @@ func NewCommands() *kingpin.Application {
 	app.Flag("duration.initial", "initial wait time").Default("1s").DurationVar(&Config.DurationInitial)
 	app.Flag("duration.max", "max wait time").Default("5m").DurationVar(&Config.DurationMax)
 
-	app.Flag("retry.max", "max retry, 0 means unlimited").Default("0").IntVar(&Config.RetryMax)
+	app.Flag("reset.decay", "divide wait time on every reset instead of resetting to duration.initial, e.g. 2 halves it, 0 means reset directly").Default("0").Float64Var(&Config.ResetDecay)
+	app.Flag("reset.cooldown", "ignore all wait time resets until program running for cooldown, 0 means disabled").Default("0s").DurationVar(&Config.ResetCooldown)
+	app.Flag("reset.uptime_interval", "reset wait time once per interval of uptime when program exits after running longer than it, 0 means disabled").Default("0s").DurationVar(&Config.ResetUptimeInterval)
+
+	app.Flag("retry.max", "max consecutive retry, counted again after program exits successfully, 0 means unlimited").Default("0").IntVar(&Config.RetryMax)
+	app.Flag("retry.max_elapsed", "stop retrying when next run would start after it, 0 means unlimited").Default("0s").DurationVar(&Config.RetryMaxElapsed)
+
+	app.Flag("attempt.timeout", "kill program after running for timeout, 0 means unlimited").Default("0s").DurationVar(&Config.AttemptTimeout)
+	app.Flag("shutdown.timeout", "kill program if not exited after interrupted on shutdown, 0 means unlimited").Default("10s").DurationVar(&Config.ShutdownTimeout)
+
+	app.Flag("restart", "restart policy: on-failure, always, unless-stopped or never").Default(string(backoff.RestartOnFailure)).EnumVar(&Config.Restart,
+		string(backoff.RestartOnFailure), string(backoff.RestartAlways), string(backoff.RestartUnlessStopped), string(backoff.RestartNever))
+	app.Flag("min-uptime", "runs exited within min uptime are considered as failure even if exit 0").Default("0s").DurationVar(&Config.MinUptime)
+
+	app.Flag("strategy", "wait time strategy: factor, constant, linear, exponential, fibonacci or polynomial").Default(StrategyFactor).EnumVar(&Config.Strategy,
+		StrategyFactor, StrategyConstant, StrategyLinear, StrategyExponential, StrategyFibonacci, StrategyPolynomial)
+	app.Flag("strategy.step", "linear strategy step").Default("1s").DurationVar(&Config.StrategyStep)
+	app.Flag("strategy.multiplier", "exponential strategy multiplier").Default("2").Float64Var(&Config.StrategyMultiplier)
+	app.Flag("strategy.degree", "polynomial strategy degree").Default("2").Float64Var(&Config.StrategyDegree)
 
 	app.Flag("factor.exponent", "exponent factor").Default("1").IntVar(&Config.FactorExponent)
 	app.Flag("factor.const.inter", "inter const factor").Default("0s").DurationVar(&Config.FactorConstInter)
-	app.Flag("factor.const.outer", "outer const factor").Default("0s").DurationVar(&Config.FactorConstInter)
+	app.Flag("factor.const.outer", "outer const factor").Default("0s").DurationVar(&Config.FactorConstOuter)
+
+	app.Flag("jitter", "wait time jitter: none, full, equal or decorrelated").Default(string(backoff.JitterNone)).EnumVar(&Config.Jitter,
+		string(backoff.JitterNone), string(backoff.JitterFull), string(backoff.JitterEqual), string(backoff.JitterDecorrelated))
+	app.Flag("jitter.seed", "jitter random seed, 0 means random").Default("0").Uint64Var(&Config.JitterSeed)
+
+	app.Flag("exit.success", "exit codes considered as success, separated by comma").PlaceHolder("CODE").StringsVar(&Config.ExitSuccess)
+	app.Flag("exit.no_retry", "exit codes or signals stop retrying, separated by comma").PlaceHolder("CODE|SIGNAL").StringsVar(&Config.ExitNoRetry)
+	app.Flag("exit.retry", "only retry on these exit codes or signals if set, separated by comma").PlaceHolder("CODE|SIGNAL").StringsVar(&Config.ExitRetry)
+	app.Flag("exit.retry_spawn_failure", "retry when program failed to start, e.g. not found or permission denied").Default("false").BoolVar(&Config.ExitRetrySpawnFailure)
 
 	app.Flag("probe.initial.delay", "probe health check initial delay").Default("1s").DurationVar(&Config.ProbeInitialDelay)
 	app.Flag("probe.interval", "probe health check interval").Default("5s").DurationVar(&Config.ProbeInterval)
 	app.Flag("probe.threshold.success", "probe health check success threshold").Default("1").IntVar(&Config.ProbeThresholdSuccess)
 	app.Flag("probe.threshold.failure", "probe health check failure threshold").Default("5").IntVar(&Config.ProbeThresholdFailure)
+	app.Flag("probe.duration.initial", "initial wait time after killed by health check, 0 means same as duration.initial").Default("0s").DurationVar(&Config.ProbeDurationInitial)
+	app.Flag("probe.duration.max", "max wait time after killed by health check, 0 means same as duration.max").Default("0s").DurationVar(&Config.ProbeDurationMax)
 
 	app.Flag("tcp.addr", "tcp health check addr").HintOptions("127.0.0.1:80").StringVar(&Config.TcpAddr)
 	app.Flag("tcp.timeout", "tcp health check handshake timeout").Default("20s").DurationVar(&Config.TcpTimeout)
@@ -2,6 +2,7 @@ package config
 
 import (
 	"github.com/Mmx233/BackoffCli/backoff"
+	"math/rand/v2"
 	"time"
 )
 
@@ -15,7 +16,31 @@ type _Config struct {
 	DurationInitial time.Duration
 	DurationMax     time.Duration
 
-	RetryMax int
+	ResetDecay          float64
+	ResetCooldown       time.Duration
+	ResetUptimeInterval time.Duration
+
+	RetryMax        int
+	RetryMaxElapsed time.Duration
+
+	AttemptTimeout  time.Duration
+	ShutdownTimeout time.Duration
+
+	Restart   string
+	MinUptime time.Duration
+
+	Strategy           string
+	StrategyStep       time.Duration
+	StrategyMultiplier float64
+	StrategyDegree     float64
+
+	Jitter     string
+	JitterSeed uint64
+
+	ExitSuccess           []string
+	ExitNoRetry           []string
+	ExitRetry             []string
+	ExitRetrySpawnFailure bool
 
 	FactorExponent   int
 	FactorConstInter time.Duration
@@ -25,6 +50,8 @@ type _Config struct {
 	ProbeInterval         time.Duration
 	ProbeThresholdSuccess int
 	ProbeThresholdFailure int
+	ProbeDurationInitial  time.Duration
+	ProbeDurationMax      time.Duration
 
 	TcpAddr    string
 	TcpTimeout time.Duration
@@ -41,12 +68,60 @@ type _Config struct {
 
 func (c _Config) NewBackoffConf(logger backoff.Logger) backoff.Conf {
 	return backoff.Conf{
-		Logger:           logger,
-		InitialDuration:  c.DurationInitial,
-		MaxDuration:      c.DurationMax,
-		MaxRetry:         uint(c.RetryMax),
-		ExponentFactor:   c.FactorExponent,
-		InterConstFactor: c.FactorConstInter,
-		OuterConstFactor: c.FactorConstOuter,
+		Logger:              logger,
+		InitialDuration:     c.DurationInitial,
+		MaxDuration:         c.DurationMax,
+		ResetDecay:          c.ResetDecay,
+		ResetCooldown:       c.ResetCooldown,
+		ResetUptimeInterval: c.ResetUptimeInterval,
+		MaxRetry:            uint(c.RetryMax),
+		MaxElapsedTime:      c.RetryMaxElapsed,
+		AttemptTimeout:      c.AttemptTimeout,
+		Restart:             backoff.RestartPolicy(c.Restart),
+		MinUptime:           c.MinUptime,
+		Strategy:            c.NewStrategy(),
+		Jitter:              backoff.Jitter(c.Jitter),
+		Rand:                c.NewRand(),
+		ExponentFactor:      c.FactorExponent,
+		InterConstFactor:    c.FactorConstInter,
+		OuterConstFactor:    c.FactorConstOuter,
+		HealthCheckWait:     c.NewHealthCheckWait(),
+	}
+}
+
+// NewHealthCheckWait returns nil if not set, health check failures share wait time with crashes.
+func (c _Config) NewHealthCheckWait() *backoff.WaitPolicy {
+	if c.ProbeDurationInitial == 0 && c.ProbeDurationMax == 0 {
+		return nil
+	}
+	return &backoff.WaitPolicy{
+		InitialDuration: c.ProbeDurationInitial,
+		MaxDuration:     c.ProbeDurationMax,
+	}
+}
+
+// NewStrategy returns nil for factor strategy, which is the backoff default.
+func (c _Config) NewStrategy() backoff.Strategy {
+	switch c.Strategy {
+	case StrategyConstant:
+		return backoff.ConstantStrategy{}
+	case StrategyLinear:
+		return backoff.LinearStrategy{Step: c.StrategyStep}
+	case StrategyExponential:
+		return backoff.ExponentialStrategy{Multiplier: c.StrategyMultiplier}
+	case StrategyFibonacci:
+		return backoff.FibonacciStrategy{Unit: c.DurationInitial}
+	case StrategyPolynomial:
+		return backoff.PolynomialStrategy{Unit: c.DurationInitial, Degree: c.StrategyDegree}
+	default:
+		return nil
+	}
+}
+
+// NewRand returns nil when seed is not set, backoff will use global random source.
+func (c _Config) NewRand() backoff.Rand {
+	if c.JitterSeed == 0 {
+		return nil
 	}
+	return rand.New(rand.NewPCG(c.JitterSeed, c.JitterSeed))
 }
@@ -3,3 +3,18 @@ package config
 const (
 	LogKeyComponent = "comp"
 )
+
+const (
+	EnvAttempt      = "BACKOFF_ATTEMPT"
+	EnvLastExitCode = "BACKOFF_LAST_EXIT_CODE"
+	EnvLastWait     = "BACKOFF_LAST_WAIT"
+)
+
+const (
+	StrategyFactor      = "factor"
+	StrategyConstant    = "constant"
+	StrategyLinear      = "linear"
+	StrategyExponential = "exponential"
+	StrategyFibonacci   = "fibonacci"
+	StrategyPolynomial  = "polynomial"
+)
@@ -2,7 +2,9 @@ package singleton
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"github.com/Mmx233/BackoffCli/backoff"
 	"github.com/Mmx233/BackoffCli/internal/config"
 	"github.com/Mmx233/BackoffCli/pipe"
 	log "github.com/sirupsen/logrus"
@@ -10,6 +12,7 @@ import (
 	"net"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -56,10 +59,77 @@ type Singleton struct {
 	Pipe       pipe.Pipe
 	HttpClient *http.Client
 	Shutdown   func()
+	// Runner is controlled through http server on pipe once set
+	Runner atomic.Pointer[backoff.Runner]
 
 	Logger log.FieldLogger
 }
 
+type Status struct {
+	State     backoff.RunnerState `json:"state"`
+	Attempt   uint                `json:"attempt"`
+	Wait      string              `json:"wait"`
+	WaitUntil *time.Time          `json:"wait_until,omitempty"`
+	LastError string              `json:"last_error,omitempty"`
+	Health    backoff.HealthState `json:"health"`
+	// HealthCheck is the last health check result of the running program
+	HealthCheck *HealthCheckStatus `json:"health_check,omitempty"`
+}
+
+type HealthCheckStatus struct {
+	Status  backoff.HealthStatus `json:"status"`
+	Probe   string               `json:"probe,omitempty"`
+	Message string               `json:"message,omitempty"`
+	Latency string               `json:"latency"`
+	Time    time.Time            `json:"time"`
+}
+
+func NewStatus(snapshot backoff.Snapshot) Status {
+	status := Status{
+		State:   snapshot.State,
+		Attempt: snapshot.Attempt,
+		Wait:    snapshot.Wait.String(),
+		Health:  snapshot.Health,
+	}
+	if !snapshot.WaitUntil.IsZero() {
+		status.WaitUntil = &snapshot.WaitUntil
+	}
+	if snapshot.LastError != nil {
+		status.LastError = snapshot.LastError.Error()
+	}
+	if result := snapshot.HealthCheck; result.Status != "" {
+		status.HealthCheck = &HealthCheckStatus{
+			Status:  result.Status,
+			Probe:   result.Probe,
+			Message: result.Message,
+			Latency: result.Latency.String(),
+			Time:    result.Time,
+		}
+	}
+	return status
+}
+
+func (s *Singleton) _HandleRunner(w http.ResponseWriter, path string) {
+	runner := s.Runner.Load()
+	if runner == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	switch path {
+	case "/status":
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(NewStatus(runner.Snapshot()))
+		return
+	case "/pause":
+		runner.Pause()
+	case "/resume":
+		runner.Resume()
+	case "/retry":
+		runner.RetryNow()
+	}
+	_, _ = w.Write([]byte("ok"))
+}
+
 func (s *Singleton) RequestExit(ctx context.Context) error {
 	req, err := http.NewRequest("GET", "http://backoff/exit", nil)
 	if err != nil {
@@ -115,6 +185,8 @@ func (s *Singleton) Run(ctx context.Context, quit func()) error {
 			case "/exit":
 				_, _ = w.Write([]byte("ok"))
 				go s.Shutdown()
+			case "/status", "/pause", "/resume", "/retry":
+				s._HandleRunner(w, r.URL.Path)
 			default:
 				w.WriteHeader(http.StatusNotFound)
 			}
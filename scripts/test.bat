@@ -1,3 +1,3 @@
 @echo off
-go test ./backoff
+go test ./backoff/...
 go test .
\ No newline at end of file